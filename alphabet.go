@@ -0,0 +1,153 @@
+package gexorank
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/lupppig/gexorank/internal/alphabet"
+)
+
+// Alphabet is an ordered character set used to encode rank values.
+//
+// Characters are single bytes in strictly ascending byte order, so rank values
+// sort correctly under byte-wise (binary/"C") collation. Larger alphabets
+// produce shorter keys for the same number of positions.
+//
+// An Alphabet is immutable and safe for concurrent use. Alphabets built from
+// the same characters are the same pointer, so ranks encoded in them compare
+// equal with ==.
+type Alphabet struct {
+	set *alphabet.Set
+}
+
+var (
+	// Base36 is the default alphabet: 0-9a-z.
+	Base36 = internAlphabet(&Alphabet{set: alphabet.Base36})
+
+	// Base62 is 0-9A-Za-z. It requires a case-sensitive, byte-wise collation
+	// on the rank column.
+	Base62 = mustAlphabet("0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz")
+
+	// Base95 is every printable ASCII character from ' ' (0x20) to '~' (0x7e).
+	// It requires a byte-wise collation and a column type that preserves
+	// leading and trailing spaces.
+	Base95 = mustAlphabet(printableASCII())
+)
+
+// NewAlphabet creates an Alphabet from a user-supplied character set.
+// chars must contain at least three bytes in strictly ascending byte order:
+// with two, the middle character used by [Ranker.Initial] would be the
+// maximum, leaving no room above the initial rank.
+func NewAlphabet(chars string) (*Alphabet, error) {
+	if len(chars) < 3 {
+		return nil, fmt.Errorf("gexorank: invalid alphabet: need at least 3 characters, got %d", len(chars))
+	}
+	if a, ok := alphabets.Load(chars); ok {
+		return a.(*Alphabet), nil
+	}
+	set, err := alphabet.New(chars)
+	if err != nil {
+		return nil, fmt.Errorf("gexorank: invalid alphabet: %w", err)
+	}
+	return internAlphabet(&Alphabet{set: set}), nil
+}
+
+// alphabets maps the characters of every Alphabet created so far to it.
+var alphabets sync.Map
+
+// internAlphabet returns the Alphabet already created from a's characters,
+// or registers and returns a.
+func internAlphabet(a *Alphabet) *Alphabet {
+	v, _ := alphabets.LoadOrStore(a.String(), a)
+	return v.(*Alphabet)
+}
+
+func mustAlphabet(chars string) *Alphabet {
+	a, err := NewAlphabet(chars)
+	if err != nil {
+		panic(err)
+	}
+	return a
+}
+
+func printableASCII() string {
+	var b strings.Builder
+	for c := byte(' '); c <= '~'; c++ {
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+// String returns the ordered characters of the alphabet.
+func (a *Alphabet) String() string {
+	return a.set.Chars()
+}
+
+// Size returns the number of characters in the alphabet (its numeric base).
+func (a *Alphabet) Size() int {
+	return a.set.Size()
+}
+
+// Min returns the minimum character of the alphabet.
+func (a *Alphabet) Min() byte {
+	return a.set.Min()
+}
+
+// Max returns the maximum character of the alphabet.
+func (a *Alphabet) Max() byte {
+	return a.set.Max()
+}
+
+// Mid returns the middle character of the alphabet.
+func (a *Alphabet) Mid() byte {
+	return a.set.Mid()
+}
+
+// Validate checks that every byte in s belongs to the alphabet.
+func (a *Alphabet) Validate(s string) error {
+	return a.set.Validate(s)
+}
+
+// MinValue returns the minimum rank value of the given length.
+func (a *Alphabet) MinValue(length int) RankValue {
+	return newRankValue(strings.Repeat(string(a.Min()), length), a)
+}
+
+// MaxValue returns the maximum rank value of the given length.
+func (a *Alphabet) MaxValue(length int) RankValue {
+	return newRankValue(strings.Repeat(string(a.Max()), length), a)
+}
+
+// MidValue returns the midpoint rank value of the given length.
+func (a *Alphabet) MidValue(length int) RankValue {
+	return newRankValue(strings.Repeat(string(a.Mid()), length), a)
+}
+
+// ParseRankValue validates and creates a RankValue encoded in this alphabet.
+// The string must not be empty.
+func (a *Alphabet) ParseRankValue(s string) (RankValue, error) {
+	if len(s) == 0 {
+		return RankValue{}, fmt.Errorf("gexorank: rank value must not be empty")
+	}
+	if err := a.Validate(s); err != nil {
+		return RankValue{}, fmt.Errorf("gexorank: invalid rank value: %w", err)
+	}
+	return newRankValue(s, a), nil
+}
+
+// orBase36 returns a, or [Base36] if a is nil. The zero RankValue carries
+// no alphabet and is treated as base36.
+func (a *Alphabet) orBase36() *Alphabet {
+	if a == nil {
+		return Base36
+	}
+	return a
+}
+
+// equal reports whether a and b hold the same characters. Alphabets built
+// separately from the same characters encode ranks identically, so they are
+// compared by content rather than by pointer.
+func (a *Alphabet) equal(b *Alphabet) bool {
+	return a.orBase36().String() == b.orBase36().String()
+}
@@ -0,0 +1,111 @@
+package gexorank_test
+
+import (
+	"testing"
+
+	"github.com/lupppig/gexorank"
+)
+
+// --- Alphabet Tests ---
+
+func TestAlphabet_Predefined(t *testing.T) {
+	tests := []struct {
+		name string
+		a    *gexorank.Alphabet
+		size int
+		min  byte
+		max  byte
+	}{
+		{"base36", gexorank.Base36, 36, '0', 'z'},
+		{"base62", gexorank.Base62, 62, '0', 'z'},
+		{"base95", gexorank.Base95, 95, ' ', '~'},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.a.Size(); got != tt.size {
+				t.Errorf("Size() = %d, want %d", got, tt.size)
+			}
+			if got := tt.a.Min(); got != tt.min {
+				t.Errorf("Min() = %q, want %q", got, tt.min)
+			}
+			if got := tt.a.Max(); got != tt.max {
+				t.Errorf("Max() = %q, want %q", got, tt.max)
+			}
+		})
+	}
+}
+
+func TestNewAlphabet_Invalid(t *testing.T) {
+	for _, chars := range []string{"", "a", "01", "ba", "abca"} {
+		if _, err := gexorank.NewAlphabet(chars); err == nil {
+			t.Errorf("NewAlphabet(%q) expected error", chars)
+		}
+	}
+}
+
+func TestAlphabet_Smallest(t *testing.T) {
+	a, err := gexorank.NewAlphabet("012")
+	if err != nil {
+		t.Fatalf("NewAlphabet: %v", err)
+	}
+	rk, err := gexorank.NewRanker(gexorank.WithAlphabet(a))
+	if err != nil {
+		t.Fatalf("NewRanker: %v", err)
+	}
+	initial, max := rk.Initial(), rk.Max()
+	if initial.String() != "0|111111" || initial.CompareTo(max) >= 0 {
+		t.Errorf("Initial() = %q, want 0|111111 below Max() %q", initial, max)
+	}
+	if next := initial.GenNext(); next.CompareTo(initial) <= 0 || next.CompareTo(max) >= 0 {
+		t.Errorf("GenNext() = %q, want between %q and %q", next, initial, max)
+	}
+}
+
+func TestAlphabet_ParseRankValue(t *testing.T) {
+	if _, err := gexorank.Base62.ParseRankValue("AbZ09z"); err != nil {
+		t.Errorf("Base62.ParseRankValue: unexpected error: %v", err)
+	}
+	if _, err := gexorank.Base36.ParseRankValue("AbZ09z"); err == nil {
+		t.Error("Base36.ParseRankValue should reject uppercase")
+	}
+	if _, err := gexorank.Base62.ParseRankValue(""); err == nil {
+		t.Error("ParseRankValue(\"\") should return error")
+	}
+}
+
+func TestAlphabet_BetweenCustom(t *testing.T) {
+	a, err := gexorank.NewAlphabet("abcd")
+	if err != nil {
+		t.Fatalf("NewAlphabet: %v", err)
+	}
+	lo := a.MinValue(3)
+	hi := a.MaxValue(3)
+	if lo.String() != "aaa" || hi.String() != "ddd" {
+		t.Fatalf("MinValue/MaxValue = %q/%q, want aaa/ddd", lo, hi)
+	}
+
+	for i := 0; i < 20; i++ {
+		mid, err := lo.Between(hi)
+		if err != nil {
+			t.Fatalf("iteration %d: Between(%q, %q): %v", i, lo, hi, err)
+		}
+		if err := a.Validate(mid.String()); err != nil {
+			t.Fatalf("iteration %d: mid %q not in alphabet: %v", i, mid, err)
+		}
+		if mid.CompareTo(lo) <= 0 || mid.CompareTo(hi) >= 0 {
+			t.Fatalf("iteration %d: mid %q not between %q and %q", i, mid, lo, hi)
+		}
+		if mid.String() <= lo.String() || mid.String() >= hi.String() {
+			t.Fatalf("iteration %d: mid %q not byte-wise between %q and %q", i, mid, lo, hi)
+		}
+		hi = mid
+	}
+}
+
+func TestAlphabet_BetweenMixed(t *testing.T) {
+	a := gexorank.Base36.MidValue(6)
+	b := gexorank.Base62.MaxValue(6)
+	if _, err := a.Between(b); err == nil {
+		t.Error("Between values of different alphabets should return error")
+	}
+}
@@ -0,0 +1,252 @@
+package gexorank
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/lupppig/gexorank/internal/alphabet"
+)
+
+// This file implements rank arithmetic directly on the encoded strings.
+// Values are treated as fixed-width numbers in the alphabet's base, with
+// missing trailing digits read as the minimum character (zero). Widths that
+// fit in a uint64 use native integers; longer values are processed digit by
+// digit. The big.Int helpers in rank.go are the fallback for stepping and
+// spreading values wider than a uint64; the big.Int reference midpoint that
+// the string arithmetic is checked against lives in arith_test.go.
+
+// stackDigits is the widest value processed in a stack-allocated buffer.
+const stackDigits = MaxLength
+
+// uint64Width returns the widest value whose doubled maximum still fits in a
+// uint64, so that two values can be summed without overflow.
+func uint64Width(set *alphabet.Set) int {
+	base := uint64(set.Size())
+	width := 0
+	for v := uint64(1); v <= math.MaxUint64/2/base; v *= base {
+		width++
+	}
+	return width
+}
+
+// digitAt returns the numeric value of s[i], or zero past the end of s.
+func digitAt(set *alphabet.Set, s string, i int) int {
+	if i >= len(s) {
+		return 0
+	}
+	return set.ToVal(s[i])
+}
+
+// toUint64 converts s, padded or truncated to width, to an integer.
+// width must not exceed uint64Width.
+func toUint64(set *alphabet.Set, s string, width int) uint64 {
+	base := uint64(set.Size())
+	var n uint64
+	for i := range width {
+		n = n*base + uint64(digitAt(set, s, i))
+	}
+	return n
+}
+
+// fromUint64 encodes n as a string of exactly width characters.
+func fromUint64(set *alphabet.Set, n uint64, width int) string {
+	var stack [64]byte
+	buf := stack[:width]
+	base := uint64(set.Size())
+	for i := width - 1; i >= 0; i-- {
+		buf[i] = set.ToChar(int(n % base))
+		n /= base
+	}
+	return string(buf)
+}
+
+// digitBuf returns a scratch buffer of n bytes, using stack when it is large enough.
+func digitBuf(stack []byte, n int) []byte {
+	if n <= len(stack) {
+		return stack[:n]
+	}
+	return make([]byte, n)
+}
+
+// midpointStr returns floor((lo + hi) / 2) with both values read at width.
+func midpointStr(set *alphabet.Set, lo, hi string, width int) string {
+	if width <= uint64Width(set) {
+		a, b := toUint64(set, lo, width), toUint64(set, hi, width)
+		return fromUint64(set, (a+b)/2, width)
+	}
+
+	base := set.Size()
+	var stack [stackDigits]byte
+	buf := digitBuf(stack[:], width)
+
+	// Sum digit by digit from the right, keeping the carry out of the top digit.
+	carry := 0
+	for i := width - 1; i >= 0; i-- {
+		sum := digitAt(set, lo, i) + digitAt(set, hi, i) + carry
+		buf[i] = byte(sum % base)
+		carry = sum / base
+	}
+
+	// Halve from the left, feeding each remainder into the next digit.
+	rem := carry
+	for i := range width {
+		cur := rem*base + int(buf[i])
+		buf[i] = set.ToChar(cur / 2)
+		rem = cur % 2
+	}
+
+	return string(buf)
+}
+
+// incrementStr returns s + 1 at its own width. The maximum value saturates.
+func incrementStr(set *alphabet.Set, s string) string {
+	i := len(s) - 1
+	for i >= 0 && s[i] == set.Max() {
+		i--
+	}
+	if i < 0 {
+		return s
+	}
+
+	var stack [stackDigits]byte
+	buf := digitBuf(stack[:], len(s))
+	copy(buf, s)
+	buf[i] = set.ToChar(set.ToVal(s[i]) + 1)
+	for j := i + 1; j < len(buf); j++ {
+		buf[j] = set.Min()
+	}
+	return string(buf)
+}
+
+// decrementStr returns s - 1 at its own width. The minimum value saturates.
+func decrementStr(set *alphabet.Set, s string) string {
+	i := len(s) - 1
+	for i >= 0 && s[i] == set.Min() {
+		i--
+	}
+	if i < 0 {
+		return s
+	}
+
+	var stack [stackDigits]byte
+	buf := digitBuf(stack[:], len(s))
+	copy(buf, s)
+	buf[i] = set.ToChar(set.ToVal(s[i]) - 1)
+	for j := i + 1; j < len(buf); j++ {
+		buf[j] = set.Max()
+	}
+	return string(buf)
+}
+
+// isMinStr reports whether every character of s is the minimum character.
+func isMinStr(set *alphabet.Set, s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] != set.Min() {
+			return false
+		}
+	}
+	return true
+}
+
+// leadingRun returns the number of leading characters of s equal to c.
+func leadingRun(s string, c byte) int {
+	n := 0
+	for n < len(s) && s[n] == c {
+		n++
+	}
+	return n
+}
+
+// comparePadded compares a and b as if the shorter were padded with the
+// minimum character. It returns -1, 0, or 1.
+func comparePadded(set *alphabet.Set, a, b string) int {
+	n := min(len(a), len(b))
+	if a[:n] < b[:n] {
+		return -1
+	}
+	if a[:n] > b[:n] {
+		return 1
+	}
+	if !isMinStr(set, a[n:]) {
+		return 1
+	}
+	if !isMinStr(set, b[n:]) {
+		return -1
+	}
+	return 0
+}
+
+// stepUpStr returns the value step above s, with s truncated or padded to
+// width. When fewer than 2*step values remain above it, the result is placed
+// halfway into the remaining headroom instead. ok is false when s is already
+// the maximum value at width.
+func stepUpStr(set *alphabet.Set, s string, width int, step uint64) (string, bool) {
+	if width <= uint64Width(set) {
+		floor := toUint64(set, s, width)
+		headroom := maxUint64At(set, width) - floor
+		if headroom == 0 {
+			return "", false
+		}
+		return fromUint64(set, floor+stepWithin(headroom, step), width), true
+	}
+
+	floor := floorAt(set, s, width)
+	limit := new(big.Int).Exp(big.NewInt(int64(set.Size())), big.NewInt(int64(width)), nil)
+	headroom := limit.Sub(limit, floor)
+	headroom.Sub(headroom, big.NewInt(1))
+	if headroom.Sign() == 0 {
+		return "", false
+	}
+	return bigIntToStr(set, floor.Add(floor, bigStepWithin(headroom, step)), width), true
+}
+
+// stepDownStr is the mirror of [stepUpStr]: it returns the value step below s
+// truncated to width, or halfway down to one when fewer than 2*step values
+// remain above zero. Zero itself is the floor of the ranking space and is
+// never returned, so ok is false when the truncated value is zero or one.
+func stepDownStr(set *alphabet.Set, s string, width int, step uint64) (string, bool) {
+	if width <= uint64Width(set) {
+		floor := toUint64(set, s, width)
+		if floor <= 1 {
+			return "", false
+		}
+		return fromUint64(set, floor-stepWithin(floor-1, step), width), true
+	}
+
+	floor := floorAt(set, s, width)
+	room := new(big.Int).Sub(floor, big.NewInt(1))
+	if room.Sign() <= 0 {
+		return "", false
+	}
+	dec := bigStepWithin(room, step)
+	return bigIntToStr(set, floor.Sub(floor, dec), width), true
+}
+
+// maxUint64At returns the largest value representable at width.
+func maxUint64At(set *alphabet.Set, width int) uint64 {
+	base := uint64(set.Size())
+	n := uint64(1)
+	for range width {
+		n *= base
+	}
+	return n - 1
+}
+
+// stepWithin returns step, or half of headroom (rounded up) when headroom is
+// smaller than 2*step. headroom must be positive.
+func stepWithin(headroom, step uint64) uint64 {
+	if headroom/2 >= step {
+		return step
+	}
+	return (headroom + 1) / 2
+}
+
+// bigStepWithin is [stepWithin] for headroom beyond uint64.
+func bigStepWithin(headroom *big.Int, step uint64) *big.Int {
+	s := new(big.Int).SetUint64(step)
+	half := new(big.Int).Rsh(headroom, 1)
+	if half.Cmp(s) >= 0 {
+		return s
+	}
+	return half.Add(headroom, big.NewInt(1)).Rsh(half, 1)
+}
@@ -0,0 +1,142 @@
+package gexorank
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/lupppig/gexorank/internal/alphabet"
+)
+
+// fuzzAlphabets are the sets exercised by the differential tests.
+var fuzzAlphabets = []*alphabet.Set{Base36.set, Base62.set, Base95.set, alphabet.MustNew("01")}
+
+// midpointBig calculates the midpoint between two equal-length strings in the
+// given alphabet. It is the arbitrary-precision reference that the tests
+// check [midpointStr] against.
+func midpointBig(set *alphabet.Set, lo, hi string) string {
+	a := strToBigInt(set, lo)
+	b := strToBigInt(set, hi)
+
+	// mid = (a + b) / 2
+	sum := new(big.Int).Add(a, b)
+	mid := new(big.Int).Div(sum, big.NewInt(2))
+
+	return bigIntToStr(set, mid, len(lo))
+}
+
+// toSet maps arbitrary fuzz bytes onto characters of set.
+func toSet(set *alphabet.Set, raw string) string {
+	var b strings.Builder
+	for i := 0; i < len(raw) && i < 300; i++ {
+		b.WriteByte(set.ToChar(int(raw[i]) % set.Size()))
+	}
+	return b.String()
+}
+
+// padTo pads s with the minimum character to width.
+func padTo(set *alphabet.Set, s string, width int) string {
+	return s + strings.Repeat(string(set.Min()), width-len(s))
+}
+
+func TestMidpointStr_FastPathBoundary(t *testing.T) {
+	set := Base36.set
+	w := uint64Width(set)
+	for _, width := range []int{w - 1, w, w + 1} {
+		lo := strings.Repeat("y", width)
+		hi := strings.Repeat("z", width)
+		if got, want := midpointStr(set, lo, hi, width), midpointBig(set, lo, hi); got != want {
+			t.Errorf("width %d: midpointStr = %q, midpointBig = %q", width, got, want)
+		}
+	}
+}
+
+func TestIncrementDecrement_Saturate(t *testing.T) {
+	set := Base36.set
+	if got := incrementStr(set, "zzz"); got != "zzz" {
+		t.Errorf("incrementStr(zzz) = %q, want zzz", got)
+	}
+	if got := decrementStr(set, "000"); got != "000" {
+		t.Errorf("decrementStr(000) = %q, want 000", got)
+	}
+	if got := incrementStr(set, "azz"); got != "b00" {
+		t.Errorf("incrementStr(azz) = %q, want b00", got)
+	}
+	if got := decrementStr(set, "b00"); got != "azz" {
+		t.Errorf("decrementStr(b00) = %q, want azz", got)
+	}
+}
+
+func FuzzMidpointStr(f *testing.F) {
+	f.Add("aaaaaa", "zzzzzz", uint8(0))
+	f.Add("iiiiii", "iiiiij", uint8(0))
+	f.Add("0", "zzzzzzzzzzzzzzzzzzzz", uint8(1))
+	f.Add("abc", "abc", uint8(2))
+	f.Add(strings.Repeat("z", 40), strings.Repeat("y", 41), uint8(3))
+
+	f.Fuzz(func(t *testing.T, rawLo, rawHi string, which uint8) {
+		set := fuzzAlphabets[int(which)%len(fuzzAlphabets)]
+		lo, hi := toSet(set, rawLo), toSet(set, rawHi)
+		width := max(len(lo), len(hi))
+		if width == 0 {
+			return
+		}
+
+		got := midpointStr(set, lo, hi, width)
+		want := midpointBig(set, padTo(set, lo, width), padTo(set, hi, width))
+		if got != want {
+			t.Fatalf("midpointStr(%q, %q, %d) = %q, midpointBig = %q", lo, hi, width, got, want)
+		}
+	})
+}
+
+func FuzzIncrementDecrement(f *testing.F) {
+	f.Add("iiiiii", uint8(0))
+	f.Add("azzzzz", uint8(0))
+	f.Add("b00000", uint8(1))
+	f.Add(strings.Repeat("z", 30), uint8(2))
+
+	f.Fuzz(func(t *testing.T, raw string, which uint8) {
+		set := fuzzAlphabets[int(which)%len(fuzzAlphabets)]
+		s := toSet(set, raw)
+		if s == "" {
+			return
+		}
+
+		n := strToBigInt(set, s)
+		limit := new(big.Int).Exp(big.NewInt(int64(set.Size())), big.NewInt(int64(len(s))), nil)
+
+		inc := new(big.Int).Add(n, big.NewInt(1))
+		if inc.Cmp(limit) >= 0 {
+			inc.Set(n)
+		}
+		if got, want := incrementStr(set, s), bigIntToStr(set, inc, len(s)); got != want {
+			t.Fatalf("incrementStr(%q) = %q, want %q", s, got, want)
+		}
+
+		dec := new(big.Int).Sub(n, big.NewInt(1))
+		if dec.Sign() < 0 {
+			dec.Set(n)
+		}
+		if got, want := decrementStr(set, s), bigIntToStr(set, dec, len(s)); got != want {
+			t.Fatalf("decrementStr(%q) = %q, want %q", s, got, want)
+		}
+	})
+}
+
+func FuzzComparePadded(f *testing.F) {
+	f.Add("abc", "abc000", uint8(0))
+	f.Add("abc", "abd", uint8(0))
+	f.Add("z", "0000001", uint8(1))
+
+	f.Fuzz(func(t *testing.T, rawA, rawB string, which uint8) {
+		set := fuzzAlphabets[int(which)%len(fuzzAlphabets)]
+		a, b := toSet(set, rawA), toSet(set, rawB)
+		width := max(len(a), len(b))
+
+		want := strToBigInt(set, padTo(set, a, width)).Cmp(strToBigInt(set, padTo(set, b, width)))
+		if got := comparePadded(set, a, b); got != want {
+			t.Fatalf("comparePadded(%q, %q) = %d, want %d", a, b, got, want)
+		}
+	})
+}
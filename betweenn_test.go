@@ -0,0 +1,113 @@
+package gexorank_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lupppig/gexorank"
+)
+
+// --- BetweenN Tests ---
+
+func TestBetweenN(t *testing.T) {
+	tests := []struct {
+		name    string
+		prev    string
+		next    string
+		n       int
+		wantLen int
+	}{
+		{"wide gap", "0|aaaaaa", "0|zzzzzz", 10, 1},
+		{"adjacent", "0|aaaaaa", "0|aaaaab", 500, 8},
+		{"append", "0|iiiiii", "", 500, 2},
+		{"prepend", "", "0|iiiiii", 35, 2},
+		{"empty list", "", "", 1000, 2},
+		{"above max", "0|zzzzzz", "", 3, 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var prev, next *gexorank.LexoRank
+			if tt.prev != "" {
+				r := mustParse(t, tt.prev)
+				prev = &r
+			}
+			if tt.next != "" {
+				r := mustParse(t, tt.next)
+				next = &r
+			}
+
+			ranks, err := gexorank.BetweenN(prev, next, tt.n)
+			if err != nil {
+				t.Fatalf("BetweenN error: %v", err)
+			}
+			if len(ranks) != tt.n {
+				t.Fatalf("BetweenN returned %d ranks, want %d", len(ranks), tt.n)
+			}
+
+			maxLen := 0
+			for i, r := range ranks {
+				maxLen = max(maxLen, r.Len())
+				if prev != nil && r.CompareTo(*prev) <= 0 {
+					t.Fatalf("ranks[%d]=%q not > prev %q", i, r, prev)
+				}
+				if next != nil && r.CompareTo(*next) >= 0 {
+					t.Fatalf("ranks[%d]=%q not < next %q", i, r, next)
+				}
+				if i > 0 && r.CompareTo(ranks[i-1]) <= 0 {
+					t.Fatalf("ranks[%d]=%q <= ranks[%d]=%q", i, r, i-1, ranks[i-1])
+				}
+			}
+			if maxLen != tt.wantLen {
+				t.Errorf("longest rank = %d chars, want %d", maxLen, tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestBetweenN_Invalid(t *testing.T) {
+	a := mustParse(t, "0|aaaaaa")
+	b := mustParse(t, "0|bbbbbb")
+	c := mustParse(t, "1|bbbbbb")
+
+	if _, err := gexorank.BetweenN(&b, &a, 3); err == nil {
+		t.Error("BetweenN with prev > next should return error")
+	}
+	if _, err := gexorank.BetweenN(&a, &a, 3); err == nil {
+		t.Error("BetweenN with equal neighbors should return error")
+	}
+	if _, err := gexorank.BetweenN(&a, &c, 3); err == nil {
+		t.Error("BetweenN across buckets should return error")
+	}
+	if _, err := gexorank.BetweenN(&a, &b, -1); err == nil {
+		t.Error("BetweenN with negative n should return error")
+	}
+	if ranks, err := gexorank.BetweenN(&a, &b, 0); err != nil || ranks != nil {
+		t.Errorf("BetweenN(0) = %v, %v; want nil, nil", ranks, err)
+	}
+}
+
+func TestBetweenN_Exhausted(t *testing.T) {
+	rk, err := gexorank.NewRanker(gexorank.WithDefaultLength(2), gexorank.WithMaxLength(3))
+	if err != nil {
+		t.Fatalf("NewRanker error: %v", err)
+	}
+	a, _ := rk.Parse("0|aaa")
+	b, _ := rk.Parse("0|aab")
+	if _, err := rk.BetweenN(&a, &b, 2); !errors.Is(err, gexorank.ErrRankExhausted) {
+		t.Errorf("BetweenN error = %v, want ErrRankExhausted", err)
+	}
+}
+
+func ExampleBetweenN() {
+	a, _ := gexorank.Parse("0|aaaaaa")
+	b, _ := gexorank.Parse("0|aaaaab")
+	ranks, _ := gexorank.BetweenN(&a, &b, 3)
+	for _, r := range ranks {
+		fmt.Println(r)
+	}
+	// Output:
+	// 0|aaaaaa9
+	// 0|aaaaaai
+	// 0|aaaaaar
+}
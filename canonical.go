@@ -0,0 +1,75 @@
+package gexorank
+
+import "fmt"
+
+// Canonical returns r with trailing minimum characters removed ("abc000" →
+// "abc"), keeping one character for the minimum value. Values that differ
+// only by such padding compare equal with [RankValue.CompareTo] but are
+// different strings, which sort differently in a database and all pass a
+// UNIQUE constraint; storing only canonical values keeps string order and
+// CompareTo order in agreement.
+func (r RankValue) Canonical() RankValue {
+	return newRankValue(canonicalStr(r.set(), r.value), r.alpha)
+}
+
+// IsCanonical reports whether r has no trailing padding.
+func (r RankValue) IsCanonical() bool {
+	return canonicalStr(r.set(), r.value) == r.value
+}
+
+// Canonical returns r with its value in canonical form (see
+// [RankValue.Canonical]). Every rank generated by this package is canonical.
+func (r LexoRank) Canonical() LexoRank {
+	r.value = r.value.Canonical()
+	return r
+}
+
+// IsCanonical reports whether r's value has no trailing padding.
+func (r LexoRank) IsCanonical() bool {
+	return r.value.IsCanonical()
+}
+
+// ParseOption configures [Parse] and [Ranker.Parse].
+type ParseOption func(*parseConfig)
+
+type parseConfig struct {
+	reject, normalize bool
+}
+
+// RejectNonCanonical makes Parse fail on values with trailing padding, such
+// as "0|abc000", so that they are caught at the boundary instead of being
+// stored next to their canonical twin.
+func RejectNonCanonical() ParseOption {
+	return func(c *parseConfig) {
+		c.reject = true
+	}
+}
+
+// Canonicalize makes Parse return values in canonical form, so "0|abc000"
+// parses as "0|abc".
+func Canonicalize() ParseOption {
+	return func(c *parseConfig) {
+		c.normalize = true
+	}
+}
+
+// applyParseOptions applies opts to a parsed rank.
+func applyParseOptions(r LexoRank, opts []ParseOption) (LexoRank, error) {
+	if len(opts) == 0 {
+		return r, nil
+	}
+	var c parseConfig
+	for _, opt := range opts {
+		opt(&c)
+	}
+	switch {
+	case r.IsCanonical():
+		return r, nil
+	case c.reject:
+		return LexoRank{}, fmt.Errorf("gexorank: rank %s is not canonical, want %s", r, r.Canonical())
+	case c.normalize:
+		return r.Canonical(), nil
+	default:
+		return r, nil
+	}
+}
@@ -0,0 +1,118 @@
+package gexorank_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/lupppig/gexorank"
+)
+
+// --- Canonical Form Tests ---
+
+func TestCanonical(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"0|abc", "0|abc"},
+		{"0|abc000", "0|abc"},
+		{"0|a0b0", "0|a0b"},
+		{"1|000000", "1|0"},
+		{"2|zzzzzz", "2|zzzzzz"},
+	}
+	for _, tt := range tests {
+		r := mustParse(t, tt.in)
+		c := r.Canonical()
+		if c.String() != tt.want {
+			t.Errorf("Canonical(%s) = %q, want %q", tt.in, c, tt.want)
+		}
+		if r.IsCanonical() != (tt.in == tt.want) {
+			t.Errorf("IsCanonical(%s) = %v", tt.in, r.IsCanonical())
+		}
+		if !c.IsCanonical() || c.CompareTo(r) != 0 {
+			t.Errorf("Canonical(%s) = %q is not a canonical equal rank", tt.in, c)
+		}
+	}
+}
+
+func TestParse_CanonicalOptions(t *testing.T) {
+	if _, err := gexorank.Parse("0|abc000", gexorank.RejectNonCanonical()); err == nil {
+		t.Error("RejectNonCanonical accepted 0|abc000")
+	}
+	if r, err := gexorank.Parse("0|abc", gexorank.RejectNonCanonical()); err != nil || r.String() != "0|abc" {
+		t.Errorf("RejectNonCanonical(0|abc) = %q, %v", r, err)
+	}
+	if r, err := gexorank.Parse("0|abc000", gexorank.Canonicalize()); err != nil || r.String() != "0|abc" {
+		t.Errorf("Canonicalize(0|abc000) = %q, %v; want 0|abc", r, err)
+	}
+	if r := mustParse(t, "0|abc000"); r.String() != "0|abc000" {
+		t.Errorf("Parse without options = %q, want the input unchanged", r)
+	}
+
+	rk, err := gexorank.NewRanker(gexorank.WithAlphabet(gexorank.Base62))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, err := rk.Parse("0|Ab00", gexorank.Canonicalize()); err != nil || r.String() != "0|Ab" {
+		t.Errorf("Ranker.Parse(0|Ab00, Canonicalize) = %q, %v; want 0|Ab", r, err)
+	}
+}
+
+func TestGenerators_Canonical(t *testing.T) {
+	check := func(what string, r gexorank.LexoRank) {
+		t.Helper()
+		if !r.IsCanonical() {
+			t.Fatalf("%s = %q is not canonical", what, r)
+		}
+	}
+
+	a, b := mustParse(t, "0|a"), mustParse(t, "0|b")
+	for range 200 {
+		mid, err := gexorank.Between(a, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		check("Between", mid)
+		b = mid
+	}
+
+	r := gexorank.Initial()
+	check("Initial", r)
+	for range 2000 {
+		r = r.GenNext()
+		check("GenNext", r)
+	}
+	r = gexorank.Initial()
+	for range 2000 {
+		r = r.GenPrev()
+		check("GenPrev", r)
+	}
+
+	ranks, err := gexorank.BetweenN(nil, nil, 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range ranks {
+		check("BetweenN", r)
+	}
+	rebalanced, err := gexorank.Rebalance(ranks, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range rebalanced {
+		check("Rebalance", r)
+	}
+	if report := gexorank.Validate(rebalanced); len(report.Issues) != 0 {
+		t.Errorf("Validate(Rebalance) = %v, want no issues", report)
+	}
+}
+
+func ExampleCanonicalize() {
+	r, _ := gexorank.Parse("0|hzz000", gexorank.Canonicalize())
+	fmt.Println(r)
+
+	_, err := gexorank.Parse("0|hzz000", gexorank.RejectNonCanonical())
+	fmt.Println(err)
+	// Output:
+	// 0|hzz
+	// gexorank: rank 0|hzz000 is not canonical, want 0|hzz
+}
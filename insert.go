@@ -1,6 +1,12 @@
 package gexorank
 
-import "fmt"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
 
 // ErrMaxRetriesExceeded is returned when [InsertBetween] exhausts all retry
 // attempts without a successful insert.
@@ -22,8 +28,10 @@ type InsertFunc func(rank LexoRank) error
 //  2. Computes a new rank via [GenBetween].
 //  3. Calls insert with the computed rank.
 //
-// If insert returns an error, the cycle restarts (up to maxRetries total
-// attempts). If all attempts fail, [ErrMaxRetriesExceeded] is returned.
+// If insert returns an error, the cycle restarts immediately (up to
+// maxRetries total attempts). If all attempts fail, [ErrMaxRetriesExceeded]
+// is returned. Use [InsertBetweenContext] to wait between attempts and retry
+// only on conflicts.
 //
 // The caller is responsible for adding a UNIQUE constraint on the rank column
 // so that concurrent duplicate inserts cause a conflict error.
@@ -42,29 +50,194 @@ type InsertFunc func(rank LexoRank) error
 //	    3,
 //	)
 func InsertBetween(neighbors NeighborFunc, insert InsertFunc, maxRetries int) (LexoRank, error) {
-	if maxRetries < 1 {
-		maxRetries = 1
+	res, err := InsertBetweenContext(context.Background(),
+		func(context.Context) (*LexoRank, *LexoRank, error) { return neighbors() },
+		func(_ context.Context, rank LexoRank) error { return insert(rank) },
+		WithMaxAttempts(maxRetries),
+		WithBackoff(ConstantBackoff(0)),
+		WithConflict(func(error) bool { return true }),
+	)
+	return res.Rank, err
+}
+
+// NeighborContextFunc is the context-aware form of [NeighborFunc].
+type NeighborContextFunc func(ctx context.Context) (prev, next *LexoRank, err error)
+
+// InsertContextFunc is the context-aware form of [InsertFunc]. Errors that
+// the conflict classifier (see [WithConflict]) does not recognize stop the
+// retry loop.
+type InsertContextFunc func(ctx context.Context, rank LexoRank) error
+
+// Backoff returns how long to wait before the given retry; retry is 1 before
+// the second attempt, 2 before the third, and so on.
+type Backoff func(retry int) time.Duration
+
+// ConstantBackoff waits d before every retry.
+func ConstantBackoff(d time.Duration) Backoff {
+	return func(int) time.Duration { return d }
+}
+
+// ExponentialBackoff doubles the wait before every retry, starting at base
+// and capped at limit, and picks a random wait between half and all of it so
+// that writers that collided once do not collide again in lockstep.
+func ExponentialBackoff(base, limit time.Duration) Backoff {
+	return func(retry int) time.Duration {
+		d := base
+		for i := 1; i < retry && d < limit; i++ {
+			d *= 2
+		}
+		d = min(d, limit)
+		if d <= 1 {
+			return d
+		}
+		half := d / 2
+		return half + rand.N(d-half+1)
 	}
+}
+
+// IsConflict reports whether err is a rank conflict, that is whether it wraps
+// [ErrConflict]. It is the default classifier of [InsertBetweenContext].
+func IsConflict(err error) bool {
+	return errors.Is(err, ErrConflict)
+}
 
-	var lastErr error
-	for range maxRetries {
-		prev, next, err := neighbors()
+// InsertOption configures [InsertBetweenContext].
+type InsertOption func(*insertConfig)
+
+type insertConfig struct {
+	attempts   int
+	backoff    Backoff
+	isConflict func(error) bool
+}
+
+// WithMaxAttempts sets the total number of attempts, including the first.
+// The default is 3; values below 1 mean 1.
+func WithMaxAttempts(n int) InsertOption {
+	return func(c *insertConfig) {
+		c.attempts = max(n, 1)
+	}
+}
+
+// WithBackoff sets the wait between attempts. The default is
+// ExponentialBackoff(10*time.Millisecond, time.Second).
+func WithBackoff(b Backoff) InsertOption {
+	return func(c *insertConfig) {
+		c.backoff = b
+	}
+}
+
+// WithConflict sets the classifier that decides whether an insert error is a
+// rank collision worth retrying. The default is [IsConflict]; pass a function
+// that recognizes your driver's unique-violation error when the insert does
+// not translate it to [ErrConflict]:
+//
+//	gexorank.WithConflict(func(err error) bool {
+//	    var pgErr *pgconn.PgError
+//	    return errors.As(err, &pgErr) && pgErr.Code == "23505"
+//	})
+func WithConflict(isConflict func(error) bool) InsertOption {
+	return func(c *insertConfig) {
+		c.isConflict = isConflict
+	}
+}
+
+// InsertResult describes a call to [InsertBetweenContext].
+type InsertResult struct {
+	// Rank is the inserted rank, or the zero value if no insert succeeded.
+	Rank LexoRank
+	// Attempts is the number of times insert was called.
+	Attempts int
+	// Errs holds the error of every failed attempt, oldest first.
+	Errs []error
+}
+
+// InsertBetweenContext is the context-aware form of [InsertBetween]. Only
+// insert errors accepted by the conflict classifier are retried, after the
+// wait given by the backoff; any other error, a failing neighbors call or a
+// done ctx ends the loop at once.
+//
+// The returned error wraps everything that went wrong, so [errors.Is] and
+// [errors.As] see through it: when the attempts run out it wraps
+// [ErrMaxRetriesExceeded] and every conflict error; when ctx is done it wraps
+// the context's error and the last conflict; otherwise it wraps the fatal
+// error. The result is filled in either way.
+//
+//	res, err := gexorank.InsertBetweenContext(ctx, neighbors, insert,
+//	    gexorank.WithMaxAttempts(5),
+//	    gexorank.WithBackoff(gexorank.ExponentialBackoff(5*time.Millisecond, 200*time.Millisecond)),
+//	)
+//
+// Ranks are computed with [GenBetween], so the first rank of an empty list
+// comes from [Default]; use [Ranker.InsertBetweenContext] for other settings.
+func InsertBetweenContext(ctx context.Context, neighbors NeighborContextFunc, insert InsertContextFunc, opts ...InsertOption) (InsertResult, error) {
+	return insertBetween(ctx, GenBetween, neighbors, insert, opts)
+}
+
+// InsertBetweenContext is [InsertBetweenContext] with ranks computed by
+// [Ranker.GenBetween], so inserts into an empty list start at rk's initial
+// rank.
+func (rk *Ranker) InsertBetweenContext(ctx context.Context, neighbors NeighborContextFunc, insert InsertContextFunc, opts ...InsertOption) (InsertResult, error) {
+	return insertBetween(ctx, rk.GenBetween, neighbors, insert, opts)
+}
+
+// insertBetween runs the retry loop of [InsertBetweenContext], computing each
+// rank with gen.
+func insertBetween(ctx context.Context, gen func(prev, next *LexoRank) (LexoRank, error), neighbors NeighborContextFunc, insert InsertContextFunc, opts []InsertOption) (InsertResult, error) {
+	c := insertConfig{
+		attempts:   3,
+		backoff:    ExponentialBackoff(10*time.Millisecond, time.Second),
+		isConflict: IsConflict,
+	}
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	var res InsertResult
+	for {
+		if err := context.Cause(ctx); err != nil {
+			return res, res.canceled(err)
+		}
+
+		prev, next, err := neighbors(ctx)
 		if err != nil {
-			return LexoRank{}, fmt.Errorf("gexorank: neighbors: %w", err)
+			return res, fmt.Errorf("gexorank: neighbors: %w", err)
 		}
 
-		rank, err := GenBetween(prev, next)
+		rank, err := gen(prev, next)
 		if err != nil {
-			return LexoRank{}, fmt.Errorf("gexorank: gen rank: %w", err)
+			return res, fmt.Errorf("gexorank: gen rank: %w", err)
 		}
 
-		if err := insert(rank); err != nil {
-			lastErr = err
-			continue
+		res.Attempts++
+		err = insert(ctx, rank)
+		if err == nil {
+			res.Rank = rank
+			return res, nil
+		}
+		res.Errs = append(res.Errs, err)
+		if !c.isConflict(err) {
+			return res, fmt.Errorf("gexorank: insert: %w", err)
+		}
+		if res.Attempts >= c.attempts {
+			return res, fmt.Errorf("%w after %d attempts: %w", ErrMaxRetriesExceeded, res.Attempts, errors.Join(res.Errs...))
 		}
 
-		return rank, nil
+		if d := c.backoff(res.Attempts); d > 0 {
+			t := time.NewTimer(d)
+			select {
+			case <-ctx.Done():
+				t.Stop()
+				return res, res.canceled(context.Cause(ctx))
+			case <-t.C:
+			}
+		}
 	}
+}
 
-	return LexoRank{}, fmt.Errorf("%w: last error: %v", ErrMaxRetriesExceeded, lastErr)
+// canceled wraps the cause of a done context and the last attempt's error.
+func (res *InsertResult) canceled(cause error) error {
+	if len(res.Errs) == 0 {
+		return fmt.Errorf("gexorank: insert: %w", cause)
+	}
+	return fmt.Errorf("gexorank: insert after %d attempts: %w (last error: %w)", res.Attempts, cause, res.Errs[len(res.Errs)-1])
 }
@@ -0,0 +1,161 @@
+package gexorank_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+	"time"
+
+	"github.com/lupppig/gexorank"
+)
+
+// --- InsertBetweenContext Tests ---
+
+func appendAfter(r gexorank.LexoRank) gexorank.NeighborContextFunc {
+	return func(context.Context) (*gexorank.LexoRank, *gexorank.LexoRank, error) {
+		return &r, nil, nil
+	}
+}
+
+func TestInsertBetweenContext_RetriesConflicts(t *testing.T) {
+	var waits []int
+	backoff := func(retry int) time.Duration {
+		waits = append(waits, retry)
+		return time.Microsecond
+	}
+	calls := 0
+	res, err := gexorank.InsertBetweenContext(context.Background(), appendAfter(gexorank.Initial()),
+		func(_ context.Context, r gexorank.LexoRank) error {
+			calls++
+			if calls < 3 {
+				return fmt.Errorf("insert %s: %w", r, gexorank.ErrConflict)
+			}
+			return nil
+		},
+		gexorank.WithMaxAttempts(5), gexorank.WithBackoff(backoff),
+	)
+	if err != nil {
+		t.Fatalf("InsertBetweenContext error: %v", err)
+	}
+	if res.Attempts != 3 || len(res.Errs) != 2 || res.Rank.CompareTo(gexorank.Initial()) <= 0 {
+		t.Errorf("result = %+v, want 3 attempts, 2 errors and a rank after Initial", res)
+	}
+	if fmt.Sprint(waits) != "[1 2]" {
+		t.Errorf("backoff called with %v, want [1 2]", waits)
+	}
+}
+
+func TestInsertBetweenContext_Exhausted(t *testing.T) {
+	res, err := gexorank.InsertBetweenContext(context.Background(), appendAfter(gexorank.Initial()),
+		func(context.Context, gexorank.LexoRank) error { return gexorank.ErrConflict },
+		gexorank.WithMaxAttempts(4), gexorank.WithBackoff(gexorank.ConstantBackoff(0)),
+	)
+	if !errors.Is(err, gexorank.ErrMaxRetriesExceeded) || !errors.Is(err, gexorank.ErrConflict) {
+		t.Errorf("error = %v, want ErrMaxRetriesExceeded wrapping ErrConflict", err)
+	}
+	if res.Attempts != 4 || len(res.Errs) != 4 {
+		t.Errorf("result = %+v, want 4 attempts and errors", res)
+	}
+}
+
+func TestInsertBetweenContext_FatalError(t *testing.T) {
+	connErr := &fs.PathError{Op: "dial", Path: "db", Err: errors.New("connection refused")}
+	res, err := gexorank.InsertBetweenContext(context.Background(), appendAfter(gexorank.Initial()),
+		func(context.Context, gexorank.LexoRank) error { return connErr },
+	)
+	var pathErr *fs.PathError
+	if !errors.As(err, &pathErr) || errors.Is(err, gexorank.ErrMaxRetriesExceeded) {
+		t.Errorf("error = %v, want the connection error without a retry", err)
+	}
+	if res.Attempts != 1 {
+		t.Errorf("Attempts = %d, want 1", res.Attempts)
+	}
+}
+
+func TestInsertBetweenContext_Conflict(t *testing.T) {
+	dup := errors.New("pq: duplicate key value violates unique constraint")
+	calls := 0
+	_, err := gexorank.InsertBetweenContext(context.Background(), appendAfter(gexorank.Initial()),
+		func(context.Context, gexorank.LexoRank) error {
+			calls++
+			return dup
+		},
+		gexorank.WithMaxAttempts(2), gexorank.WithBackoff(gexorank.ConstantBackoff(0)),
+		gexorank.WithConflict(func(err error) bool { return errors.Is(err, dup) }),
+	)
+	if calls != 2 || !errors.Is(err, dup) {
+		t.Errorf("calls = %d, error = %v; want 2 calls and the driver error", calls, err)
+	}
+}
+
+func TestInsertBetweenContext_Canceled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	start := time.Now()
+	res, err := gexorank.InsertBetweenContext(ctx, appendAfter(gexorank.Initial()),
+		func(context.Context, gexorank.LexoRank) error {
+			cancel()
+			return gexorank.ErrConflict
+		},
+		gexorank.WithBackoff(gexorank.ConstantBackoff(time.Hour)),
+	)
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, gexorank.ErrConflict) {
+		t.Errorf("error = %v, want context.Canceled and the last conflict", err)
+	}
+	if res.Attempts != 1 || time.Since(start) > time.Minute {
+		t.Errorf("Attempts = %d after %v, want 1 without waiting", res.Attempts, time.Since(start))
+	}
+
+	_, err = gexorank.InsertBetweenContext(ctx, appendAfter(gexorank.Initial()),
+		func(context.Context, gexorank.LexoRank) error {
+			t.Fatal("insert called with a done context")
+			return nil
+		},
+	)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("done context: error = %v, want context.Canceled", err)
+	}
+}
+
+func TestRanker_InsertBetweenContext(t *testing.T) {
+	rk, err := gexorank.NewRanker(gexorank.WithAlphabet(gexorank.Base62), gexorank.WithDefaultLength(4))
+	if err != nil {
+		t.Fatalf("NewRanker error: %v", err)
+	}
+	empty := func(context.Context) (*gexorank.LexoRank, *gexorank.LexoRank, error) { return nil, nil, nil }
+	var inserted gexorank.LexoRank
+	res, err := rk.InsertBetweenContext(context.Background(), empty,
+		func(_ context.Context, r gexorank.LexoRank) error {
+			inserted = r
+			return nil
+		},
+	)
+	if err != nil {
+		t.Fatalf("InsertBetweenContext error: %v", err)
+	}
+	if want := rk.Initial(); res.Rank.String() != want.String() || inserted.String() != want.String() {
+		t.Errorf("first rank of an empty list = %q, want the ranker's Initial %q", res.Rank, want)
+	}
+}
+
+func TestExponentialBackoff(t *testing.T) {
+	b := gexorank.ExponentialBackoff(10*time.Millisecond, 100*time.Millisecond)
+	tests := []struct {
+		retry int
+		want  time.Duration
+	}{
+		{1, 10 * time.Millisecond},
+		{2, 20 * time.Millisecond},
+		{4, 80 * time.Millisecond},
+		{5, 100 * time.Millisecond},
+		{60, 100 * time.Millisecond},
+	}
+	for _, tt := range tests {
+		for range 50 {
+			if d := b(tt.retry); d < tt.want/2 || d > tt.want {
+				t.Fatalf("retry %d: wait %v, want within [%v, %v]", tt.retry, d, tt.want/2, tt.want)
+			}
+		}
+	}
+}
@@ -1,8 +1,10 @@
-// Package alphabet provides a base36 character set for LexoRank value encoding.
+// Package alphabet provides ordered character sets for LexoRank value encoding.
 //
-// The alphabet maps characters 0-9a-z to integer values 0–35 and back.
-// It is used internally by the rank value logic to convert between
-// string-based ranks and numeric representations for arithmetic.
+// A [Set] maps each of its characters to an integer value (its position in
+// the set) and back. It is used internally by the rank value logic to convert
+// between string-based ranks and numeric representations for arithmetic.
+//
+// The package-level functions operate on the default base36 set (0-9a-z).
 package alphabet
 
 import "fmt"
@@ -13,60 +15,139 @@ const Size = 36
 // chars is the ordered base36 character set.
 const chars = "0123456789abcdefghijklmnopqrstuvwxyz"
 
-// charToVal maps each base36 rune to its integer value.
-var charToVal [256]int
+// Base36 is the default character set (0-9a-z).
+var Base36 = MustNew(chars)
 
-func init() {
-	for i := range charToVal {
-		charToVal[i] = -1
+// Set is an ordered character set. Characters must be single bytes in
+// strictly ascending byte order, so that byte-wise string comparison of
+// encoded values matches their numeric order.
+//
+// A Set is immutable and safe for concurrent use.
+type Set struct {
+	chars     string
+	charToVal [256]int
+}
+
+// New creates a Set from chars. It returns an error if chars has fewer than
+// two characters or is not in strictly ascending byte order.
+func New(chars string) (*Set, error) {
+	if len(chars) < 2 {
+		return nil, fmt.Errorf("alphabet: need at least 2 characters, got %d", len(chars))
+	}
+	s := &Set{chars: chars}
+	for i := range s.charToVal {
+		s.charToVal[i] = -1
 	}
-	for i, c := range chars {
-		charToVal[c] = i
+	for i := 0; i < len(chars); i++ {
+		if i > 0 && chars[i] <= chars[i-1] {
+			return nil, fmt.Errorf("alphabet: characters must be in strictly ascending byte order, %q at position %d follows %q", chars[i], i, chars[i-1])
+		}
+		s.charToVal[chars[i]] = i
 	}
+	return s, nil
+}
+
+// MustNew is like [New] but panics on error. It is intended for
+// package-level variable initialization.
+func MustNew(chars string) *Set {
+	s, err := New(chars)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+// Chars returns the ordered characters of the set.
+func (s *Set) Chars() string {
+	return s.chars
+}
+
+// Size returns the number of characters in the set.
+func (s *Set) Size() int {
+	return len(s.chars)
+}
+
+// Min returns the minimum character in the set.
+func (s *Set) Min() byte {
+	return s.chars[0]
+}
+
+// Max returns the maximum character in the set.
+func (s *Set) Max() byte {
+	return s.chars[len(s.chars)-1]
+}
+
+// Mid returns the middle character in the set, rounding up. For a set of
+// two characters that is the maximum.
+func (s *Set) Mid() byte {
+	return s.chars[len(s.chars)/2]
+}
+
+// ToChar converts an integer value to its character.
+// It panics if val is out of range.
+func (s *Set) ToChar(val int) byte {
+	if val < 0 || val >= len(s.chars) {
+		panic(fmt.Sprintf("alphabet: value %d out of range [0, %d)", val, len(s.chars)))
+	}
+	return s.chars[val]
+}
+
+// ToVal converts a character to its integer value.
+// It returns -1 if the character is not in the set.
+func (s *Set) ToVal(c byte) int {
+	return s.charToVal[c]
+}
+
+// IsValid reports whether c is in the set.
+func (s *Set) IsValid(c byte) bool {
+	return s.charToVal[c] >= 0
+}
+
+// Validate checks that every byte in str is in the set.
+// It returns an error referencing the first invalid character found.
+func (s *Set) Validate(str string) error {
+	for i := 0; i < len(str); i++ {
+		if !s.IsValid(str[i]) {
+			return fmt.Errorf("alphabet: invalid character %q at position %d", str[i], i)
+		}
+	}
+	return nil
 }
 
 // Min returns the minimum character in the alphabet ('0').
 func Min() byte {
-	return chars[0]
+	return Base36.Min()
 }
 
 // Max returns the maximum character in the alphabet ('z').
 func Max() byte {
-	return chars[Size-1]
+	return Base36.Max()
 }
 
 // Mid returns the middle character in the alphabet ('i').
 func Mid() byte {
-	return chars[Size/2]
+	return Base36.Mid()
 }
 
 // ToChar converts an integer value (0–35) to its base36 character.
 // It panics if val is out of range.
 func ToChar(val int) byte {
-	if val < 0 || val >= Size {
-		panic(fmt.Sprintf("alphabet: value %d out of range [0, %d)", val, Size))
-	}
-	return chars[val]
+	return Base36.ToChar(val)
 }
 
 // ToVal converts a base36 character to its integer value (0–35).
 // It returns -1 if the character is not in the alphabet.
 func ToVal(c byte) int {
-	return charToVal[c]
+	return Base36.ToVal(c)
 }
 
 // IsValid reports whether c is a valid base36 character.
 func IsValid(c byte) bool {
-	return charToVal[c] >= 0
+	return Base36.IsValid(c)
 }
 
 // Validate checks that every byte in s is a valid base36 character.
 // It returns an error referencing the first invalid character found.
 func Validate(s string) error {
-	for i := 0; i < len(s); i++ {
-		if !IsValid(s[i]) {
-			return fmt.Errorf("alphabet: invalid character %q at position %d", s[i], i)
-		}
-	}
-	return nil
+	return Base36.Validate(s)
 }
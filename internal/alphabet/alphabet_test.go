@@ -109,3 +109,45 @@ func TestValidate(t *testing.T) {
 		})
 	}
 }
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name    string
+		chars   string
+		wantErr bool
+	}{
+		{"base36", "0123456789abcdefghijklmnopqrstuvwxyz", false},
+		{"binary", "01", false},
+		{"empty", "", true},
+		{"single", "a", true},
+		{"unsorted", "ba", true},
+		{"duplicate", "aab", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := alphabet.New(tt.chars)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("New(%q) error = %v, wantErr %v", tt.chars, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSet_MinMaxMid(t *testing.T) {
+	s := alphabet.MustNew("ACEGI")
+	if got := s.Min(); got != 'A' {
+		t.Errorf("Min() = %q, want 'A'", got)
+	}
+	if got := s.Max(); got != 'I' {
+		t.Errorf("Max() = %q, want 'I'", got)
+	}
+	if got := s.Mid(); got != 'E' {
+		t.Errorf("Mid() = %q, want 'E'", got)
+	}
+	if got := s.ToVal('G'); got != 3 {
+		t.Errorf("ToVal('G') = %d, want 3", got)
+	}
+	if s.IsValid('B') {
+		t.Error("IsValid('B') = true, want false")
+	}
+}
@@ -0,0 +1,112 @@
+package gexorank
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand/v2"
+	"sync"
+)
+
+// jitterSpread is the minimum number of candidate positions a jittered
+// midpoint is drawn from. Two concurrent inserts into the same gap collide
+// with probability of at most 1/jitterSpread.
+const jitterSpread = 1 << 14
+
+// WithJitter makes [Ranker.Between], [Ranker.GenBetween], [LexoRank.GenNext]
+// and [LexoRank.GenPrev] pick a random position instead of the exact midpoint
+// or step, so concurrent inserters working from the same neighbors almost
+// never produce the same rank.
+//
+// Between places the rank uniformly in the middle half of the gap, extending
+// the value (up to the max length) until that half holds at least 16384
+// positions. GenNext and GenPrev move by a random distance between half and
+// one and a half steps.
+//
+// Random numbers are drawn from src, which the Ranker guards with a mutex.
+// Pass a seeded source such as [rand.NewPCG] for deterministic tests, or nil
+// to use the runtime's random generator.
+func WithJitter(src rand.Source) Option {
+	return func(rk *Ranker) {
+		rk.rng = &lockedRand{}
+		if src != nil {
+			rk.rng.r = rand.New(src)
+		}
+	}
+}
+
+// lockedRand serializes access to a non-concurrent random source.
+type lockedRand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+// Uint64N returns a uniform random number in [0, n). n must be positive.
+func (l *lockedRand) Uint64N(n uint64) uint64 {
+	if l.r == nil {
+		return rand.Uint64N(n)
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.r.Uint64N(n)
+}
+
+// jitterStep returns a random step in [step/2, step + step/2].
+func (rk *Ranker) jitterStep(step uint64) uint64 {
+	if rk.rng == nil || step < 2 {
+		return step
+	}
+	half := step / 2
+	if half > (math.MaxUint64-step)/2 {
+		half = (math.MaxUint64 - step) / 2
+	}
+	return step - half + rk.rng.Uint64N(2*half+1)
+}
+
+// jitteredMidpoint returns a random, untrimmed value in the middle half of
+// the gap between a and b, extending precision until the middle half holds at
+// least jitterSpread positions or maxLength is reached.
+func (rk *Ranker) jitteredMidpoint(a, b RankValue, maxLength int) (string, error) {
+	if !a.Alphabet().equal(b.Alphabet()) {
+		return "", fmt.Errorf("gexorank: cannot compute midpoint of rank values in different alphabets")
+	}
+	if a.CompareTo(b) == 0 {
+		return "", fmt.Errorf("gexorank: cannot compute midpoint of equal rank values")
+	}
+	if a.CompareTo(b) > 0 {
+		a, b = b, a
+	}
+
+	set := a.set()
+	spread := big.NewInt(jitterSpread)
+	width := min(max(a.Len(), b.Len()), maxLength)
+	var lo, gap *big.Int
+	for {
+		lo = floorAt(set, a.value, width)
+		gap = new(big.Int).Sub(floorAt(set, b.value, width), lo)
+		if new(big.Int).Rsh(gap, 1).Cmp(spread) >= 0 || width >= maxLength {
+			break
+		}
+		width++
+	}
+	if gap.Cmp(big.NewInt(2)) < 0 {
+		return "", ErrRankExhausted
+	}
+
+	// offset = max(1, gap/4) + rand(max(1, gap/2)), which stays within (0, gap).
+	offset := new(big.Int).Rsh(gap, 2)
+	if offset.Sign() == 0 {
+		offset.SetInt64(1)
+	}
+	span := new(big.Int).Rsh(gap, 1)
+	if span.Sign() == 0 {
+		span.SetInt64(1)
+	}
+	n := uint64(math.MaxUint64)
+	if span.IsUint64() {
+		n = span.Uint64()
+	}
+	offset.Add(offset, new(big.Int).SetUint64(rk.rng.Uint64N(n)))
+
+	return bigIntToStr(set, lo.Add(lo, offset), width), nil
+}
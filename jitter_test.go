@@ -0,0 +1,112 @@
+package gexorank_test
+
+import (
+	"math/rand/v2"
+	"sync"
+	"testing"
+
+	"github.com/lupppig/gexorank"
+)
+
+// --- Jitter Tests ---
+
+func newJitterRanker(t *testing.T, src rand.Source) *gexorank.Ranker {
+	t.Helper()
+	rk, err := gexorank.NewRanker(gexorank.WithJitter(src))
+	if err != nil {
+		t.Fatalf("NewRanker error: %v", err)
+	}
+	return rk
+}
+
+func TestJitter_Deterministic(t *testing.T) {
+	a := mustParse(t, "0|aaaaaa")
+	b := mustParse(t, "0|zzzzzz")
+
+	rk1 := newJitterRanker(t, rand.NewPCG(1, 2))
+	rk2 := newJitterRanker(t, rand.NewPCG(1, 2))
+	for i := 0; i < 10; i++ {
+		m1, err := rk1.Between(a, b)
+		if err != nil {
+			t.Fatalf("Between error: %v", err)
+		}
+		m2, _ := rk2.Between(a, b)
+		if m1.String() != m2.String() {
+			t.Fatalf("iteration %d: same seed produced %q and %q", i, m1, m2)
+		}
+	}
+}
+
+func TestJitter_BetweenRarelyCollides(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+	}{
+		{"wide gap", "0|aaaaaa", "0|zzzzzz"},
+		{"stepped neighbors", "0|iiiiii", "0|iijiii"},
+		{"adjacent", "0|iiiiii", "0|iiiiij"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rk := newJitterRanker(t, rand.NewPCG(7, 7))
+			a, b := mustParse(t, tt.a), mustParse(t, tt.b)
+
+			seen := make(map[string]bool)
+			for i := 0; i < 1000; i++ {
+				mid, err := rk.GenBetween(&a, &b)
+				if err != nil {
+					t.Fatalf("GenBetween error: %v", err)
+				}
+				if mid.CompareTo(a) <= 0 || mid.CompareTo(b) >= 0 {
+					t.Fatalf("mid %q not between %q and %q", mid, a, b)
+				}
+				seen[mid.String()] = true
+			}
+			if len(seen) < 950 {
+				t.Errorf("only %d distinct ranks out of 1000", len(seen))
+			}
+		})
+	}
+}
+
+func TestJitter_GenNext(t *testing.T) {
+	rk := newJitterRanker(t, rand.NewPCG(3, 4))
+	r := rk.Initial()
+	seen := make(map[string]bool)
+	for i := 0; i < 1000; i++ {
+		next := r.GenNext()
+		if next.CompareTo(r) <= 0 {
+			t.Fatalf("append %d: %q should be > %q", i, next, r)
+		}
+		// Canonical values drop trailing '0's, so they can be shorter.
+		if next.Len() > gexorank.DefaultLength {
+			t.Fatalf("append %d: Len() = %d, want at most %d", i, next.Len(), gexorank.DefaultLength)
+		}
+		seen[rk.Initial().GenNext().String()] = true
+		r = next
+	}
+	if len(seen) < 950 {
+		t.Errorf("only %d distinct appends after Initial out of 1000", len(seen))
+	}
+}
+
+func TestJitter_ConcurrentUse(t *testing.T) {
+	rk := newJitterRanker(t, nil)
+	a := mustParse(t, "0|aaaaaa")
+	b := mustParse(t, "0|bbbbbb")
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 100 {
+				if _, err := rk.Between(a, b); err != nil {
+					t.Errorf("Between error: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,160 @@
+package gexorank
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// ErrLeaseExhausted is returned by [Lease.Next] once every rank of the lease
+// has been handed out. Request a new lease after [Lease.Last].
+var ErrLeaseExhausted = errors.New("gexorank: lease exhausted")
+
+// MaxLeaseSize is the largest number of ranks one call to [NewLease] or
+// [NewLeases] can reserve. A lease holds its ranks in memory, about 64 bytes
+// each at the default length, so the cap keeps a block to a few megabytes;
+// reserve more by chaining leases after [Lease.Last].
+const MaxLeaseSize = 1 << 16
+
+// Lease is a block of ranks reserved for one writer, so that high-throughput
+// workers appending to the same list do not fight over the same gap through
+// [InsertBetween]. The ranks are computed and held in memory when the lease
+// is created, so its size is capped at [MaxLeaseSize]; Next hands them out in
+// strictly increasing order without touching the database.
+//
+// Reserving the block is the only coordinated step: record [Lease.Last] (for
+// example in a leases table, or in the coordinator that starts the workers)
+// and create the next lease after it, so that blocks never overlap.
+//
+// A Lease is safe for concurrent use.
+type Lease struct {
+	mu    sync.Mutex
+	ranks []LexoRank
+	next  int
+}
+
+// NewLease reserves n ranks between prev and next. Either pointer may be
+// nil:
+//   - If next is nil, the ranks are appended after prev by repeated
+//     [LexoRank.GenNext], so a lease of n ranks uses up only n steps of the
+//     tail.
+//   - If prev is nil, they are prepended before next by repeated
+//     [LexoRank.GenPrev].
+//   - If both are provided, they are spread across the gap as by [BetweenN].
+//   - If both are nil, they start at [Initial].
+//
+// n must be between 1 and [MaxLeaseSize]. It returns [ErrRankExhausted] if n
+// ranks do not fit. The [Ranker] that produced the neighbors is used.
+func NewLease(prev, next *LexoRank, n int) (*Lease, error) {
+	return rankerOf(prev, next).NewLease(prev, next, n)
+}
+
+// NewLeases reserves a block of n ranks for each of workers writers between
+// prev and next, as by [NewLease], and splits it into consecutive leases:
+// every rank of leases[i] sorts before every rank of leases[i+1]. The whole
+// block, workers*n ranks, must not exceed [MaxLeaseSize].
+func NewLeases(prev, next *LexoRank, workers, n int) ([]*Lease, error) {
+	return rankerOf(prev, next).NewLeases(prev, next, workers, n)
+}
+
+// NewLease reserves n ranks between prev and next using this Ranker's
+// alphabet and limits. See the package-level [NewLease].
+func (rk *Ranker) NewLease(prev, next *LexoRank, n int) (*Lease, error) {
+	ranks, err := rk.reserve(prev, next, n)
+	if err != nil {
+		return nil, err
+	}
+	return &Lease{ranks: ranks}, nil
+}
+
+// NewLeases reserves workers leases of n ranks each between prev and next.
+// See the package-level [NewLeases].
+func (rk *Ranker) NewLeases(prev, next *LexoRank, workers, n int) ([]*Lease, error) {
+	if workers < 1 {
+		return nil, fmt.Errorf("gexorank: cannot lease to %d workers", workers)
+	}
+	if n > MaxLeaseSize/workers {
+		return nil, fmt.Errorf("gexorank: cannot lease %d ranks to each of %d workers, over %d in total", n, workers, MaxLeaseSize)
+	}
+	ranks, err := rk.reserve(prev, next, workers*n)
+	if err != nil {
+		return nil, err
+	}
+	leases := make([]*Lease, workers)
+	for i := range leases {
+		leases[i] = &Lease{ranks: ranks[i*n : (i+1)*n : (i+1)*n]}
+	}
+	return leases, nil
+}
+
+// reserve returns n ascending ranks between prev and next.
+func (rk *Ranker) reserve(prev, next *LexoRank, n int) ([]LexoRank, error) {
+	if n < 1 || n > MaxLeaseSize {
+		return nil, fmt.Errorf("gexorank: cannot lease %d ranks, want 1 to %d", n, MaxLeaseSize)
+	}
+	if prev != nil && next != nil {
+		return rk.BetweenN(prev, next, n)
+	}
+
+	step, anchor := rk.genNext, prev
+	if next != nil {
+		step, anchor = rk.genPrev, next
+	}
+	ranks := make([]LexoRank, 0, n)
+	cur := rk.Initial()
+	if anchor == nil {
+		ranks = append(ranks, cur)
+	} else {
+		if !anchor.value.Alphabet().equal(rk.alpha) {
+			return nil, fmt.Errorf("gexorank: rank %s is not encoded in the ranker's alphabet", anchor)
+		}
+		cur = *anchor
+	}
+	for len(ranks) < n {
+		stepped := step(cur)
+		if stepped.CompareTo(cur) == 0 {
+			return nil, fmt.Errorf("%w: no room to lease %d ranks", ErrRankExhausted, n)
+		}
+		cur = stepped
+		ranks = append(ranks, cur)
+	}
+	if next != nil {
+		slices.Reverse(ranks)
+	}
+	return ranks, nil
+}
+
+// Next returns the next rank of the lease, or [ErrLeaseExhausted].
+func (l *Lease) Next() (LexoRank, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.next == len(l.ranks) {
+		return LexoRank{}, ErrLeaseExhausted
+	}
+	l.next++
+	return l.ranks[l.next-1], nil
+}
+
+// Remaining returns the number of ranks Next has yet to hand out.
+func (l *Lease) Remaining() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.ranks) - l.next
+}
+
+// Len returns the number of ranks reserved by the lease.
+func (l *Lease) Len() int {
+	return len(l.ranks)
+}
+
+// First returns the lowest rank of the lease.
+func (l *Lease) First() LexoRank {
+	return l.ranks[0]
+}
+
+// Last returns the highest rank of the lease. Pass it as prev to reserve the
+// next block after this one.
+func (l *Lease) Last() LexoRank {
+	return l.ranks[len(l.ranks)-1]
+}
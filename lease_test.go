@@ -0,0 +1,203 @@
+package gexorank_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/lupppig/gexorank"
+)
+
+// --- Lease Tests ---
+
+// drain returns every rank left in l, checking that they strictly ascend.
+func drain(t *testing.T, l *gexorank.Lease) []gexorank.LexoRank {
+	t.Helper()
+	var out []gexorank.LexoRank
+	for {
+		r, err := l.Next()
+		if errors.Is(err, gexorank.ErrLeaseExhausted) {
+			return out
+		}
+		if err != nil {
+			t.Fatalf("Next error: %v", err)
+		}
+		if len(out) > 0 && r.CompareTo(out[len(out)-1]) <= 0 {
+			t.Fatalf("rank %q not after %q", r, out[len(out)-1])
+		}
+		out = append(out, r)
+	}
+}
+
+func TestNewLease(t *testing.T) {
+	tail, head := mustParse(t, "0|iiiiii"), mustParse(t, "0|iiiiij")
+	tests := []struct {
+		name       string
+		prev, next *gexorank.LexoRank
+	}{
+		{"append", &tail, nil},
+		{"prepend", nil, &tail},
+		{"between", &tail, &head},
+		{"empty list", nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := gexorank.NewLease(tt.prev, tt.next, 1000)
+			if err != nil {
+				t.Fatalf("NewLease error: %v", err)
+			}
+			if l.Len() != 1000 || l.Remaining() != 1000 {
+				t.Fatalf("Len() = %d, Remaining() = %d; want 1000", l.Len(), l.Remaining())
+			}
+			ranks := drain(t, l)
+			if len(ranks) != 1000 || l.Remaining() != 0 {
+				t.Fatalf("drained %d ranks, Remaining() = %d", len(ranks), l.Remaining())
+			}
+			if ranks[0] != l.First() || ranks[999] != l.Last() {
+				t.Errorf("First/Last = %q/%q, want %q/%q", l.First(), l.Last(), ranks[0], ranks[999])
+			}
+			if tt.prev != nil && l.First().CompareTo(*tt.prev) <= 0 {
+				t.Errorf("First() = %q, not after prev %q", l.First(), tt.prev)
+			}
+			if tt.next != nil && l.Last().CompareTo(*tt.next) >= 0 {
+				t.Errorf("Last() = %q, not before next %q", l.Last(), tt.next)
+			}
+			if tt.prev == nil || tt.next == nil {
+				for _, r := range ranks {
+					if r.Len() > gexorank.DefaultLength {
+						t.Fatalf("rank %q longer than the default length", r)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestNewLease_Chained(t *testing.T) {
+	// A coordinator hands out consecutive tail leases; ranks never overlap
+	// even though the workers draw from them in any interleaving.
+	prev := gexorank.Initial()
+	var leases []*gexorank.Lease
+	for range 3 {
+		l, err := gexorank.NewLease(&prev, nil, 100)
+		if err != nil {
+			t.Fatal(err)
+		}
+		leases = append(leases, l)
+		prev = l.Last()
+	}
+	var all []gexorank.LexoRank
+	for _, l := range leases {
+		all = append(all, drain(t, l)...)
+	}
+	if !gexorank.IsSorted(all) || len(all) != 300 {
+		t.Fatalf("leased ranks out of order or lost: %d", len(all))
+	}
+	for i := 1; i < len(all); i++ {
+		if all[i].CompareTo(all[i-1]) == 0 {
+			t.Fatalf("rank %q leased twice", all[i])
+		}
+	}
+}
+
+func TestNewLeases(t *testing.T) {
+	a, b := mustParse(t, "0|a"), mustParse(t, "0|b")
+	leases, err := gexorank.NewLeases(&a, &b, 4, 50)
+	if err != nil {
+		t.Fatalf("NewLeases error: %v", err)
+	}
+	if len(leases) != 4 {
+		t.Fatalf("got %d leases, want 4", len(leases))
+	}
+	for i, l := range leases {
+		if l.Len() != 50 {
+			t.Errorf("lease %d has %d ranks, want 50", i, l.Len())
+		}
+		if i > 0 && l.First().CompareTo(leases[i-1].Last()) <= 0 {
+			t.Errorf("lease %d starts at %q, not after %q", i, l.First(), leases[i-1].Last())
+		}
+	}
+	if leases[0].First().CompareTo(a) <= 0 || leases[3].Last().CompareTo(b) >= 0 {
+		t.Errorf("leases span %q..%q, outside the gap", leases[0].First(), leases[3].Last())
+	}
+}
+
+func TestNewLease_Errors(t *testing.T) {
+	a := gexorank.Initial()
+	for _, n := range []int{0, -1, gexorank.MaxLeaseSize + 1} {
+		if _, err := gexorank.NewLease(&a, nil, n); err == nil {
+			t.Errorf("NewLease(n=%d) succeeded, want error", n)
+		}
+	}
+	if _, err := gexorank.NewLeases(&a, nil, 0, 10); err == nil {
+		t.Error("NewLeases(workers=0) succeeded, want error")
+	}
+	if _, err := gexorank.NewLeases(&a, nil, 4, gexorank.MaxLeaseSize/2); err == nil {
+		t.Error("NewLeases over MaxLeaseSize succeeded, want error")
+	}
+	lo, hi := mustParse(t, "0|a"), mustParse(t, "0|b")
+	if _, err := gexorank.NewLease(&lo, &hi, gexorank.MaxLeaseSize); err != nil {
+		t.Errorf("NewLease(n=MaxLeaseSize) error: %v", err)
+	}
+	floor := gexorank.Min()
+	if _, err := gexorank.NewLease(nil, &floor, 2); !errors.Is(err, gexorank.ErrRankExhausted) {
+		t.Errorf("lease before Min: error = %v, want ErrRankExhausted", err)
+	}
+
+	rk, err := gexorank.NewRanker(gexorank.WithDefaultLength(2), gexorank.WithMaxLength(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	last := rk.Max()
+	if _, err := rk.NewLease(&last, nil, 1); !errors.Is(err, gexorank.ErrRankExhausted) {
+		t.Errorf("lease after Max: error = %v, want ErrRankExhausted", err)
+	}
+}
+
+func TestLease_Concurrent(t *testing.T) {
+	l, err := gexorank.NewLease(nil, nil, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var mu sync.Mutex
+	seen := map[string]bool{}
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			for {
+				r, err := l.Next()
+				if err != nil {
+					return
+				}
+				mu.Lock()
+				if seen[r.String()] {
+					t.Errorf("rank %q handed out twice", r)
+				}
+				seen[r.String()] = true
+				mu.Unlock()
+			}
+		})
+	}
+	wg.Wait()
+	if len(seen) != 1000 {
+		t.Errorf("handed out %d ranks, want 1000", len(seen))
+	}
+}
+
+func ExampleNewLease() {
+	tail := gexorank.Initial()
+	lease, _ := gexorank.NewLease(&tail, nil, 3)
+
+	for {
+		rank, err := lease.Next()
+		if errors.Is(err, gexorank.ErrLeaseExhausted) {
+			break
+		}
+		fmt.Println(rank)
+	}
+	// Output:
+	// 0|iijiii
+	// 0|iikiii
+	// 0|iiliii
+}
@@ -2,8 +2,9 @@
 //
 // LexoRank assigns string-based ranks to items so that inserting or reordering
 // an item only requires updating a single row, not re-indexing the entire list.
-// Ranks are lexicographically sortable base36 strings prefixed by a bucket
-// identifier (e.g. "0|hzzzzz").
+// Ranks are lexicographically sortable strings prefixed by a bucket
+// identifier (e.g. "0|hzzzzz"). Values are base36 by default; see [Alphabet]
+// for larger character sets that produce shorter keys.
 //
 // The package is designed to be:
 //   - Immutable and concurrency-safe (no mutexes needed).
@@ -13,7 +14,7 @@
 // # Quick Start
 //
 //	first := gexorank.Initial()                          // "0|iiiiii"
-//	second := first.GenNext()                            // "0|rrrrrr"
+//	second := first.GenNext()                            // "0|iijiii"
 //	between, err := gexorank.Between(first, second)      // midpoint
 //
 // # Rebalancing
@@ -25,13 +26,11 @@ package gexorank
 
 import (
 	"database/sql/driver"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math/big"
 	"sort"
-	"strings"
-
-	"github.com/lupppig/gexorank/internal/alphabet"
 )
 
 const (
@@ -47,14 +46,21 @@ var ErrRankExhausted = errors.New("gexorank: rank exhausted, rebalancing require
 // The string format is "{bucket}|{value}", e.g. "0|hzzzzz".
 //
 // LexoRank values are safe for concurrent use because they are immutable.
+// Two ranks are == when they have the same bucket and value string and come
+// from equally configured [Ranker]s, so they can be used as map keys.
 type LexoRank struct {
 	bucket Bucket
 	value  RankValue
+	ranker *Ranker
 }
 
 // Scan implements [database/sql.Scanner] so a LexoRank can be read directly
 // from a database column. The column value must be a string or []byte in the
 // format "{bucket}|{value}".
+//
+// If r already holds a rank from a [Ranker], the value is parsed with that
+// Ranker; otherwise it is parsed with [Parse]. Use [Ranker.Scanner] to scan
+// ranks of a custom Ranker into a zero LexoRank.
 func (r *LexoRank) Scan(src any) error {
 	var s string
 	switch v := src.(type) {
@@ -65,7 +71,7 @@ func (r *LexoRank) Scan(src any) error {
 	default:
 		return fmt.Errorf("gexorank: cannot scan %T into LexoRank", src)
 	}
-	parsed, err := Parse(s)
+	parsed, err := r.rk().Parse(s)
 	if err != nil {
 		return err
 	}
@@ -88,21 +94,21 @@ func (r LexoRank) MarshalJSON() ([]byte, error) {
 	if r.value.value == "" {
 		return []byte("null"), nil
 	}
-	return []byte(`"` + r.String() + `"`), nil
+	return json.Marshal(r.String())
 }
 
 // UnmarshalJSON implements [encoding/json.Unmarshaler] so a LexoRank can be
-// deserialized from a JSON string.
+// deserialized from a JSON string. Like [LexoRank.Scan], it parses with r's
+// Ranker if r already holds a rank.
 func (r *LexoRank) UnmarshalJSON(data []byte) error {
 	if string(data) == "null" {
 		return nil
 	}
-	// Strip surrounding quotes.
-	s := string(data)
-	if len(s) >= 2 && s[0] == '"' && s[len(s)-1] == '"' {
-		s = s[1 : len(s)-1]
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("gexorank: cannot unmarshal %s into LexoRank: %w", data, err)
 	}
-	parsed, err := Parse(s)
+	parsed, err := r.rk().Parse(s)
 	if err != nil {
 		return err
 	}
@@ -115,9 +121,10 @@ func (r LexoRank) MarshalText() ([]byte, error) {
 	return []byte(r.String()), nil
 }
 
-// UnmarshalText implements [encoding.TextUnmarshaler].
+// UnmarshalText implements [encoding.TextUnmarshaler]. Like [LexoRank.Scan],
+// it parses with r's Ranker if r already holds a rank.
 func (r *LexoRank) UnmarshalText(data []byte) error {
-	parsed, err := Parse(string(data))
+	parsed, err := r.rk().Parse(string(data))
 	if err != nil {
 		return err
 	}
@@ -128,64 +135,38 @@ func (r *LexoRank) UnmarshalText(data []byte) error {
 // Parse parses a rank string in the format "{bucket}|{value}" and returns
 // a validated LexoRank. It returns an error if the format is invalid,
 // the bucket is unrecognized, or the value contains non-base36 characters.
-func Parse(s string) (LexoRank, error) {
-	parts := strings.SplitN(s, separator, 2)
-	if len(parts) != 2 {
-		return LexoRank{}, fmt.Errorf("gexorank: invalid rank format %q, expected \"{bucket}|{value}\"", s)
-	}
-
-	bucket, err := ParseBucket(parts[0])
-	if err != nil {
-		return LexoRank{}, err
-	}
-
-	value, err := ParseRankValue(parts[1])
-	if err != nil {
-		return LexoRank{}, err
-	}
-
-	return LexoRank{bucket: bucket, value: value}, nil
+// Use [Ranker.Parse] for ranks produced with other settings.
+//
+// By default values with trailing padding ("0|abc000") are accepted as is;
+// pass [RejectNonCanonical] or [Canonicalize] to refuse or normalize them.
+func Parse(s string, opts ...ParseOption) (LexoRank, error) {
+	return defaultRanker.Parse(s, opts...)
 }
 
 // Initial returns the starting rank in bucket 0 at the midpoint of the
 // ranking space. Use this to create the first rank in a new list.
 func Initial() LexoRank {
-	return LexoRank{
-		bucket: Bucket0,
-		value:  MidValue(DefaultLength),
-	}
+	return defaultRanker.Initial()
 }
 
-// Min returns the minimum possible rank in bucket 0.
+// Min returns the minimum possible rank in bucket 0, in canonical form
+// ("0|0").
 func Min() LexoRank {
-	return LexoRank{
-		bucket: Bucket0,
-		value:  MinValue(DefaultLength),
-	}
+	return defaultRanker.Min()
 }
 
 // Max returns the maximum possible rank in bucket 0.
 func Max() LexoRank {
-	return LexoRank{
-		bucket: Bucket0,
-		value:  MaxValue(DefaultLength),
-	}
+	return defaultRanker.Max()
 }
 
 // Between returns a new LexoRank that sorts between a and b.
 // Both ranks must be in the same bucket. If no midpoint can be computed
 // without exceeding [MaxLength], [ErrRankExhausted] is returned.
+//
+// The limits of the [Ranker] that produced a are enforced.
 func Between(a, b LexoRank) (LexoRank, error) {
-	if a.bucket != b.bucket {
-		return LexoRank{}, fmt.Errorf("gexorank: cannot compute midpoint across buckets %s and %s", a.bucket, b.bucket)
-	}
-
-	mid, err := a.value.Between(b.value)
-	if err != nil {
-		return LexoRank{}, err
-	}
-
-	return LexoRank{bucket: a.bucket, value: mid}, nil
+	return a.rk().Between(a, b)
 }
 
 // GenBetween returns a new LexoRank that sorts between prev and next.
@@ -202,56 +183,39 @@ func Between(a, b LexoRank) (LexoRank, error) {
 // same rank. Callers must serialize this at the database level using row-level
 // locks (SELECT … FOR UPDATE) or an optimistic retry with a UNIQUE constraint.
 //
-// This is the recommended entry point for most use cases.
+// This is the recommended entry point for most use cases. The limits of the
+// [Ranker] that produced the neighbors are enforced.
 func GenBetween(prev, next *LexoRank) (LexoRank, error) {
-	switch {
-	case prev == nil && next == nil:
-		return Initial(), nil
-	case prev == nil:
-		return next.GenPrev(), nil
-	case next == nil:
-		return prev.GenNext(), nil
-	default:
-		return Between(*prev, *next)
-	}
+	return rankerOf(prev, next).GenBetween(prev, next)
+}
+
+// BetweenN returns n ranks that sort strictly between prev and next, evenly
+// spaced and in ascending order. Either pointer may be nil to stand for the
+// bottom or top of the ranking space. Use it instead of chaining [GenBetween]
+// when inserting many items into the same gap.
+func BetweenN(prev, next *LexoRank, n int) ([]LexoRank, error) {
+	return rankerOf(prev, next).BetweenN(prev, next, n)
 }
 
 // GenNext returns a new LexoRank that sorts after r.
 //
-// It appends the midpoint character to r's value, producing a rank that
-// lexicographically sorts after r while leaving room for future inserts.
-// This is O(1) and avoids big.Int convergence toward the maximum.
+// It advances r's value by the [Ranker]'s step at the default length
+// ("iiiiii" → "iijiii"), so repeatedly appending to the end of a list keeps
+// ranks at [DefaultLength] for thousands of items while leaving room for
+// inserts in between. Longer values are truncated to the default length
+// first, which shortens them. The value only grows once every digit is
+// saturated near the top of the space.
 func (r LexoRank) GenNext() LexoRank {
-	// "iiiiii" + "i" = "iiiiiii" which sorts after "iiiiii" and before "zzzzzz".
-	v := r.value.value + string(alphabet.Mid())
-	if len(v) > MaxLength {
-		// Fallback: increment the value directly.
-		return LexoRank{bucket: r.bucket, value: r.value.Increment()}
-	}
-	return LexoRank{bucket: r.bucket, value: newRankValue(v)}
+	return r.rk().genNext(r)
 }
 
 // GenPrev returns a new LexoRank that sorts before r.
 //
-// It decrements the last character of r's value and appends the midpoint
-// character, producing a rank that sorts before r. This is O(1) and avoids
-// big.Int convergence toward the minimum.
+// It is the mirror of [LexoRank.GenNext]: the value moves down by the step
+// ("iiiiii" → "iihiii"), growing only when the digits are exhausted near the
+// bottom of the space. The minimum value is returned unchanged.
 func (r LexoRank) GenPrev() LexoRank {
-	// If value is already at minimum (all zeros), there is no rank that
-	// sorts before it. Return as-is — this is the floor of the ranking space.
-	minVal := MinValue(r.value.Len())
-	if r.value.CompareTo(minVal) == 0 {
-		return r
-	}
-
-	// "iiiiii" → decrement last → "iiiiih", then append "i" → "iiiiihi"
-	// "iiiiihi" sorts after "iiiiih" and before "iiiiii".
-	dec := r.value.Decrement()
-	v := dec.value + string(alphabet.Mid())
-	if len(v) > MaxLength {
-		return LexoRank{bucket: r.bucket, value: dec}
-	}
-	return LexoRank{bucket: r.bucket, value: newRankValue(v)}
+	return r.rk().genPrev(r)
 }
 
 // Bucket returns the bucket of this rank.
@@ -272,11 +236,12 @@ func (r LexoRank) Len() int {
 	return len(r.value.value)
 }
 
-// MaxLen returns the maximum allowed rank value length (128).
+// MaxLen returns the maximum allowed rank value length (128 unless the rank
+// was produced by a [Ranker] configured otherwise).
 // When [Len] reaches this limit, [Between] returns [ErrRankExhausted] and
 // a [Rebalance] is required.
 func (r LexoRank) MaxLen() int {
-	return MaxLength
+	return r.rk().maxLength
 }
 
 // NeedsRebalance reports whether the rank value length has reached or exceeded
@@ -289,7 +254,7 @@ func (r LexoRank) MaxLen() int {
 //	    log.Warn("ranks growing long, consider rebalancing")
 //	}
 func (r LexoRank) NeedsRebalance(threshold float64) bool {
-	return float64(r.Len()) >= threshold*float64(MaxLength)
+	return float64(r.Len()) >= threshold*float64(r.MaxLen())
 }
 
 // String returns the full rank string in the format "{bucket}|{value}".
@@ -313,13 +278,33 @@ func (r LexoRank) CompareTo(other LexoRank) int {
 // InNextBucket returns a new LexoRank with the same value but in the next
 // bucket (0→1→2→0). Use this when migrating individual ranks during rebalancing.
 func (r LexoRank) InNextBucket() LexoRank {
-	return LexoRank{bucket: r.bucket.Next(), value: r.value}
+	rk := r.rk()
+	return rk.rank(rk.NextBucket(r.bucket), r.value)
 }
 
 // InPrevBucket returns a new LexoRank with the same value but in the previous
 // bucket (0→2→1→0).
 func (r LexoRank) InPrevBucket() LexoRank {
-	return LexoRank{bucket: r.bucket.Prev(), value: r.value}
+	rk := r.rk()
+	return rk.rank(rk.PrevBucket(r.bucket), r.value)
+}
+
+// rk returns the Ranker that produced r, or [Default] for the zero value.
+func (r LexoRank) rk() *Ranker {
+	if r.ranker == nil {
+		return defaultRanker
+	}
+	return r.ranker
+}
+
+// rankerOf returns the Ranker of the first non-nil rank, or [Default].
+func rankerOf(ranks ...*LexoRank) *Ranker {
+	for _, r := range ranks {
+		if r != nil {
+			return r.rk()
+		}
+	}
+	return defaultRanker
 }
 
 // Rebalance takes a sorted slice of LexoRanks and redistributes them evenly
@@ -332,43 +317,15 @@ func (r LexoRank) InPrevBucket() LexoRank {
 //
 // The algorithm divides the ranking space into n+1 equal segments (where n
 // is the number of ranks) and assigns each rank to a segment boundary.
-func Rebalance(ranks []LexoRank, bucket Bucket) []LexoRank {
-	n := len(ranks)
-	if n == 0 {
-		return nil
-	}
-
-	result := make([]LexoRank, n)
-
-	// Use the full base36 space for DefaultLength.
-	min := strToBigInt(strings.Repeat(string(alphabet.Min()), DefaultLength))
-	max := strToBigInt(strings.Repeat(string(alphabet.Max()), DefaultLength))
-
-	// space = max - min
-	space := new(largeBigInt).Sub(max, min)
-
-	// step = space / (n + 1)
-	divisor := newLargeBigInt(int64(n + 1))
-	step := new(largeBigInt).Div(space, divisor)
-
-	// If step is zero (too many items for DefaultLength), use a longer length.
-	if step.Sign() == 0 {
-		length := DefaultLength + 2
-		min = strToBigInt(strings.Repeat(string(alphabet.Min()), length))
-		max = strToBigInt(strings.Repeat(string(alphabet.Max()), length))
-		space = new(largeBigInt).Sub(max, min)
-		step = new(largeBigInt).Div(space, divisor)
-	}
-
-	for i := 0; i < n; i++ {
-		// rank_i = min + step * (i + 1)
-		offset := new(largeBigInt).Mul(step, newLargeBigInt(int64(i+1)))
-		val := new(largeBigInt).Add(min, offset)
-		str := bigIntToStr(val, DefaultLength)
-		result[i] = LexoRank{bucket: bucket, value: newRankValue(str)}
+//
+// The [Ranker] that produced the first rank determines the alphabet and length.
+// Rebalance returns [ErrRankExhausted] if the ranks do not fit within its max
+// length, which only happens with a small [WithMaxLength].
+func Rebalance(ranks []LexoRank, bucket Bucket) ([]LexoRank, error) {
+	if len(ranks) == 0 {
+		return nil, nil
 	}
-
-	return result
+	return ranks[0].rk().Rebalance(ranks, bucket)
 }
 
 // largeBigInt is an alias to make the Rebalance code cleaner.
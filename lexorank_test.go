@@ -213,6 +213,73 @@ func TestGenPrev_MinValue(t *testing.T) {
 	}
 }
 
+func TestGenNext_StaysAtDefaultLength(t *testing.T) {
+	r := gexorank.Initial()
+	for i := 0; i < 5000; i++ {
+		next := r.GenNext()
+		if next.CompareTo(r) <= 0 {
+			t.Fatalf("append %d: %q should be > %q", i, next, r)
+		}
+		if next.Len() != gexorank.DefaultLength {
+			t.Fatalf("append %d: Len() = %d, want %d", i, next.Len(), gexorank.DefaultLength)
+		}
+		r = next
+	}
+}
+
+func TestGenPrev_StaysAtDefaultLength(t *testing.T) {
+	r := gexorank.Initial()
+	for i := 0; i < 5000; i++ {
+		prev := r.GenPrev()
+		if prev.CompareTo(r) >= 0 {
+			t.Fatalf("prepend %d: %q should be < %q", i, prev, r)
+		}
+		if prev.Len() != gexorank.DefaultLength {
+			t.Fatalf("prepend %d: Len() = %d, want %d", i, prev.Len(), gexorank.DefaultLength)
+		}
+		r = prev
+	}
+}
+
+func TestGenNext_ShortensLongRank(t *testing.T) {
+	r := mustParse(t, "0|iiiiiiabcdefgh")
+	next := r.GenNext()
+	if next.CompareTo(r) <= 0 {
+		t.Errorf("%q should be > %q", next, r)
+	}
+	if next.Len() != gexorank.DefaultLength {
+		t.Errorf("Len() = %d, want %d", next.Len(), gexorank.DefaultLength)
+	}
+}
+
+func TestGenNext_ExtendsWhenSaturated(t *testing.T) {
+	r := mustParse(t, "0|zzzzzx")
+	for i := 0; i < 10; i++ {
+		next := r.GenNext()
+		if next.CompareTo(r) <= 0 {
+			t.Fatalf("iteration %d: %q should be > %q", i, next, r)
+		}
+		r = next
+	}
+	if r.Len() <= gexorank.DefaultLength {
+		t.Errorf("Len() = %d, want growth past %d once saturated", r.Len(), gexorank.DefaultLength)
+	}
+}
+
+func TestWithStep(t *testing.T) {
+	rk, err := gexorank.NewRanker(gexorank.WithStep(1))
+	if err != nil {
+		t.Fatalf("NewRanker error: %v", err)
+	}
+	r := rk.Initial().GenNext()
+	if r.String() != "0|iiiiij" {
+		t.Errorf("GenNext() with step 1 = %q, want 0|iiiiij", r)
+	}
+	if got := gexorank.Default().Step(); got != 36*36*36 {
+		t.Errorf("Default().Step() = %d, want %d", got, 36*36*36)
+	}
+}
+
 // --- Bucket Tests ---
 
 func TestBucket_Rotation(t *testing.T) {
@@ -343,7 +410,10 @@ func TestRebalance(t *testing.T) {
 	}
 	gexorank.Sort(ranks)
 
-	rebalanced := gexorank.Rebalance(ranks, gexorank.Bucket1)
+	rebalanced, err := gexorank.Rebalance(ranks, gexorank.Bucket1)
+	if err != nil {
+		t.Fatalf("Rebalance error: %v", err)
+	}
 
 	if len(rebalanced) != len(ranks) {
 		t.Fatalf("Rebalance returned %d ranks, want %d", len(rebalanced), len(ranks))
@@ -362,16 +432,16 @@ func TestRebalance(t *testing.T) {
 }
 
 func TestRebalance_Empty(t *testing.T) {
-	result := gexorank.Rebalance(nil, gexorank.Bucket0)
-	if result != nil {
+	result, err := gexorank.Rebalance(nil, gexorank.Bucket0)
+	if result != nil || err != nil {
 		t.Errorf("Rebalance(nil) = %v, want nil", result)
 	}
 }
 
 func TestRebalance_Single(t *testing.T) {
 	r := gexorank.Initial()
-	result := gexorank.Rebalance([]gexorank.LexoRank{r}, gexorank.Bucket2)
-	if len(result) != 1 {
+	result, err := gexorank.Rebalance([]gexorank.LexoRank{r}, gexorank.Bucket2)
+	if err != nil || len(result) != 1 {
 		t.Fatalf("Rebalance single: got %d, want 1", len(result))
 	}
 	if result[0].Bucket() != gexorank.Bucket2 {
@@ -716,14 +786,14 @@ func ExampleGenBetween_append() {
 	last, _ := gexorank.Parse("0|iiiiii")
 	rank, _ := gexorank.GenBetween(&last, nil)
 	fmt.Println(rank)
-	// Output: 0|iiiiiii
+	// Output: 0|iijiii
 }
 
 func ExampleGenBetween_prepend() {
 	first, _ := gexorank.Parse("0|iiiiii")
 	rank, _ := gexorank.GenBetween(nil, &first)
 	fmt.Println(rank)
-	// Output: 0|iiiiihi
+	// Output: 0|iihiii
 }
 
 func ExampleGenBetween_insert() {
@@ -738,14 +808,14 @@ func ExampleLexoRank_GenNext() {
 	r := gexorank.Initial()
 	next := r.GenNext()
 	fmt.Println(next)
-	// Output: 0|iiiiiii
+	// Output: 0|iijiii
 }
 
 func ExampleLexoRank_GenPrev() {
 	r := gexorank.Initial()
 	prev := r.GenPrev()
 	fmt.Println(prev)
-	// Output: 0|iiiiihi
+	// Output: 0|iihiii
 }
 
 func ExampleRebalance() {
@@ -754,7 +824,11 @@ func ExampleRebalance() {
 	r3 := r2.GenNext()
 
 	ranks := []gexorank.LexoRank{r1, r2, r3}
-	rebalanced := gexorank.Rebalance(ranks, gexorank.Bucket1)
+	rebalanced, err := gexorank.Rebalance(ranks, gexorank.Bucket1)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
 	for _, r := range rebalanced {
 		fmt.Println(r)
 	}
@@ -0,0 +1,264 @@
+package gexorank
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"math"
+	"slices"
+)
+
+// DefaultRebalanceThreshold is the fraction of the max length at which an
+// [OrderedList] rebalances around a generated rank (see
+// [LexoRank.NeedsRebalance]).
+const DefaultRebalanceThreshold = 0.75
+
+// OrderedList is an in-memory list of keys ordered by LexoRank. Positions are
+// addressed by index or by key; the list generates the ranks, so callers only
+// persist what it hands back.
+//
+// Lookups by key or index are O(log n) and O(1); inserts, moves and removals
+// are O(log n) to locate plus a slice shift. When a generated rank reaches the
+// rebalance threshold, the list respaces the smallest window of neighbors
+// around it that brings every rank in the window back under the threshold
+// (see [PlanRebalance]) and reports the rewritten ranks to OnRebalance.
+//
+// An OrderedList is not safe for concurrent use. The zero value is not
+// usable; create one with [NewOrderedList].
+type OrderedList[K comparable] struct {
+	// OnRebalance, if set, is called after a local rebalance with the
+	// items whose ranks changed. Indexes refer to positions in the list
+	// after the operation that triggered it.
+	OnRebalance func(changes []RebalanceChange)
+
+	rk        *Ranker
+	threshold float64
+	entries   []listEntry[K] // sorted by rank
+	ranks     map[K]LexoRank
+}
+
+type listEntry[K comparable] struct {
+	key  K
+	rank LexoRank
+}
+
+// NewOrderedList returns an empty list that generates ranks with rk and
+// rebalances locally once a generated rank reaches threshold (0 to 1) of the
+// max length. A nil rk selects [Default]; a threshold of 0 selects
+// [DefaultRebalanceThreshold].
+func NewOrderedList[K comparable](rk *Ranker, threshold float64) (*OrderedList[K], error) {
+	if rk == nil {
+		rk = defaultRanker
+	}
+	if threshold == 0 {
+		threshold = DefaultRebalanceThreshold
+	}
+	if threshold < 0 || threshold > 1 || math.IsNaN(threshold) {
+		return nil, fmt.Errorf("gexorank: rebalance threshold %v is not between 0 and 1", threshold)
+	}
+	return &OrderedList[K]{rk: rk, threshold: threshold, ranks: make(map[K]LexoRank)}, nil
+}
+
+// Len returns the number of items in the list.
+func (l *OrderedList[K]) Len() int {
+	return len(l.entries)
+}
+
+// At returns the key and rank at index i. It panics if i is out of range.
+func (l *OrderedList[K]) At(i int) (K, LexoRank) {
+	e := l.entries[i]
+	return e.key, e.rank
+}
+
+// Rank returns the rank of key and whether it is in the list.
+func (l *OrderedList[K]) Rank(key K) (LexoRank, bool) {
+	r, ok := l.ranks[key]
+	return r, ok
+}
+
+// Index returns the position of key and whether it is in the list.
+func (l *OrderedList[K]) Index(key K) (int, bool) {
+	r, ok := l.ranks[key]
+	if !ok {
+		return -1, false
+	}
+	i, _ := l.search(r)
+	return i, true
+}
+
+// All returns an iterator over the keys and ranks in order. The list must
+// not be modified during iteration.
+func (l *OrderedList[K]) All() iter.Seq2[K, LexoRank] {
+	return func(yield func(K, LexoRank) bool) {
+		for _, e := range l.entries {
+			if !yield(e.key, e.rank) {
+				return
+			}
+		}
+	}
+}
+
+// Set adds key with an existing rank, or changes the rank of key, without
+// generating anything. Use it to load a list from storage. It returns
+// [ErrConflict] if another key has an equal rank.
+func (l *OrderedList[K]) Set(key K, rank LexoRank) error {
+	if isZeroRank(rank) {
+		return fmt.Errorf("gexorank: cannot set zero rank for %v", key)
+	}
+	if i, found := l.search(rank); found && l.entries[i].key != key {
+		return fmt.Errorf("%w: rank %s is taken by %v", ErrConflict, rank, l.entries[i].key)
+	}
+	l.Remove(key)
+	i, _ := l.search(rank)
+	l.entries = slices.Insert(l.entries, i, listEntry[K]{key, rank})
+	l.ranks[key] = rank
+	return nil
+}
+
+// InsertAt inserts key at index i, shifting later items up, and returns its
+// rank. i may equal Len() to append. It returns [ErrConflict] if key is
+// already in the list.
+func (l *OrderedList[K]) InsertAt(i int, key K) (LexoRank, error) {
+	if _, ok := l.ranks[key]; ok {
+		return LexoRank{}, fmt.Errorf("%w: %v is already in the list", ErrConflict, key)
+	}
+	if i < 0 || i > len(l.entries) {
+		return LexoRank{}, fmt.Errorf("gexorank: index %d out of range [0, %d]", i, len(l.entries))
+	}
+	return l.place(i, key)
+}
+
+// InsertBefore inserts key immediately before the item mark. It returns
+// [ErrNotFound] if mark is not in the list.
+func (l *OrderedList[K]) InsertBefore(key, mark K) (LexoRank, error) {
+	i, ok := l.Index(mark)
+	if !ok {
+		return LexoRank{}, fmt.Errorf("%w: %v", ErrNotFound, mark)
+	}
+	return l.InsertAt(i, key)
+}
+
+// InsertAfter inserts key immediately after the item mark. It returns
+// [ErrNotFound] if mark is not in the list.
+func (l *OrderedList[K]) InsertAfter(key, mark K) (LexoRank, error) {
+	i, ok := l.Index(mark)
+	if !ok {
+		return LexoRank{}, fmt.Errorf("%w: %v", ErrNotFound, mark)
+	}
+	return l.InsertAt(i+1, key)
+}
+
+// Move moves key so that it ends up at index i, and returns its rank and
+// whether it changed (see [Move]). It returns [ErrNotFound] if key is not
+// in the list.
+func (l *OrderedList[K]) Move(key K, i int) (rank LexoRank, moved bool, err error) {
+	from, ok := l.Index(key)
+	if !ok {
+		return LexoRank{}, false, fmt.Errorf("%w: %v", ErrNotFound, key)
+	}
+	if i < 0 || i >= len(l.entries) {
+		return LexoRank{}, false, fmt.Errorf("gexorank: index %d out of range [0, %d)", i, len(l.entries))
+	}
+	if i == from {
+		return l.entries[from].rank, false, nil
+	}
+
+	old := l.entries[from]
+	l.remove(from)
+	if rank, err = l.place(i, key); err != nil {
+		l.entries = slices.Insert(l.entries, from, old)
+		l.ranks[key] = old.rank
+		return LexoRank{}, false, err
+	}
+	return rank, true, nil
+}
+
+// Remove removes key and reports whether it was in the list.
+func (l *OrderedList[K]) Remove(key K) bool {
+	i, ok := l.Index(key)
+	if ok {
+		l.remove(i)
+	}
+	return ok
+}
+
+// remove deletes the entry at index i.
+func (l *OrderedList[K]) remove(i int) {
+	delete(l.ranks, l.entries[i].key)
+	l.entries = slices.Delete(l.entries, i, i+1)
+}
+
+// place generates a rank for key at index i, rebalancing around it when the
+// rank reaches the threshold or the gap is exhausted. key must not be in the
+// list.
+func (l *OrderedList[K]) place(i int, key K) (LexoRank, error) {
+	var prev, next *LexoRank
+	if i > 0 {
+		prev = &l.entries[i-1].rank
+	}
+	if i < len(l.entries) {
+		next = &l.entries[i].rank
+	}
+	rank, err := l.rk.GenBetween(prev, next)
+	switch {
+	case errors.Is(err, ErrRankExhausted):
+		// Between fails only with both neighbors set. The stand-in rank is
+		// rewritten by the rebalance; only its bucket is read.
+		rank = LexoRank{bucket: prev.bucket}
+	case err != nil:
+		return LexoRank{}, err
+	}
+	l.entries = slices.Insert(l.entries, i, listEntry[K]{key: key, rank: rank})
+	l.ranks[key] = rank
+	if err == nil && !rank.NeedsRebalance(l.threshold) {
+		return rank, nil
+	}
+
+	if rerr := l.rebalanceAround(i); rerr != nil {
+		if err != nil {
+			l.remove(i)
+			return LexoRank{}, err
+		}
+		// The rank is valid, only long; keep it.
+		return rank, nil
+	}
+	return l.entries[i].rank, nil
+}
+
+// rebalanceAround respaces the smallest window around index i whose ranks
+// fit under the threshold, and reports the changed neighbors.
+func (l *OrderedList[K]) rebalanceAround(i int) error {
+	limit := int(math.Ceil(l.threshold*float64(l.rk.maxLength))) - 1
+	if limit < 1 {
+		return ErrRankExhausted
+	}
+	ranks := make([]LexoRank, len(l.entries))
+	for j, e := range l.entries {
+		ranks[j] = e.rank
+	}
+	lo, _, fresh, err := l.rk.fitWindow(ranks, i, i+1, 0, limit)
+	if err != nil {
+		return err
+	}
+
+	var changes []RebalanceChange
+	for j, r := range fresh {
+		idx := lo + 1 + j
+		e := &l.entries[idx]
+		if idx != i && e.rank.String() != r.String() {
+			changes = append(changes, RebalanceChange{Index: idx, Old: e.rank, New: r})
+		}
+		e.rank = r
+		l.ranks[e.key] = r
+	}
+	if len(changes) > 0 && l.OnRebalance != nil {
+		l.OnRebalance(changes)
+	}
+	return nil
+}
+
+// search returns the position of r in l.entries and whether an entry with
+// an equal rank is there.
+func (l *OrderedList[K]) search(r LexoRank) (int, bool) {
+	return SearchFunc(l.entries, r, func(e listEntry[K]) LexoRank { return e.rank })
+}
@@ -0,0 +1,248 @@
+package gexorank_test
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/lupppig/gexorank"
+)
+
+// --- OrderedList Tests ---
+
+func newList(t *testing.T, rk *gexorank.Ranker, threshold float64) *gexorank.OrderedList[string] {
+	t.Helper()
+	l, err := gexorank.NewOrderedList[string](rk, threshold)
+	if err != nil {
+		t.Fatalf("NewOrderedList error: %v", err)
+	}
+	return l
+}
+
+// keys returns the keys of l in order, checking that ranks strictly ascend
+// and that Index agrees with the position.
+func keys(t *testing.T, l *gexorank.OrderedList[string]) string {
+	t.Helper()
+	var out []string
+	var last gexorank.LexoRank
+	for k, r := range l.All() {
+		if len(out) > 0 && r.CompareTo(last) <= 0 {
+			t.Fatalf("rank of %s = %q, not after %q", k, r, last)
+		}
+		if i, ok := l.Index(k); !ok || i != len(out) {
+			t.Fatalf("Index(%s) = %d, %v; want %d", k, i, ok, len(out))
+		}
+		out = append(out, k)
+		last = r
+	}
+	return strings.Join(out, ",")
+}
+
+func TestOrderedList_Insert(t *testing.T) {
+	l := newList(t, nil, 0)
+	steps := []struct {
+		op   func() (gexorank.LexoRank, error)
+		want string
+	}{
+		{func() (gexorank.LexoRank, error) { return l.InsertAt(0, "b") }, "b"},
+		{func() (gexorank.LexoRank, error) { return l.InsertAt(0, "a") }, "a,b"},
+		{func() (gexorank.LexoRank, error) { return l.InsertAt(2, "d") }, "a,b,d"},
+		{func() (gexorank.LexoRank, error) { return l.InsertAfter("c", "b") }, "a,b,c,d"},
+		{func() (gexorank.LexoRank, error) { return l.InsertBefore("x", "a") }, "x,a,b,c,d"},
+	}
+	for i, s := range steps {
+		r, err := s.op()
+		if err != nil {
+			t.Fatalf("step %d error: %v", i, err)
+		}
+		if got := keys(t, l); got != s.want {
+			t.Fatalf("step %d: list = %q, want %q", i, got, s.want)
+		}
+		if r.Len() > gexorank.DefaultLength {
+			t.Errorf("step %d: rank %q longer than the default length", i, r)
+		}
+	}
+
+	k, r := l.At(2)
+	if stored, _ := l.Rank("b"); k != "b" || r != stored {
+		t.Errorf("At(2) = %s %q, want b %q", k, r, stored)
+	}
+	if _, ok := l.Rank("zz"); ok {
+		t.Error("Rank(missing) ok = true")
+	}
+	if i, ok := l.Index("zz"); ok || i != -1 {
+		t.Errorf("Index(missing) = %d, %v; want -1, false", i, ok)
+	}
+}
+
+func TestOrderedList_Errors(t *testing.T) {
+	l := newList(t, nil, 0)
+	l.InsertAt(0, "a")
+
+	if _, err := l.InsertAt(0, "a"); !errors.Is(err, gexorank.ErrConflict) {
+		t.Errorf("duplicate key: error = %v, want ErrConflict", err)
+	}
+	for _, i := range []int{-1, 2} {
+		if _, err := l.InsertAt(i, "b"); err == nil {
+			t.Errorf("InsertAt(%d) succeeded, want error", i)
+		}
+	}
+	if _, err := l.InsertAfter("b", "zz"); !errors.Is(err, gexorank.ErrNotFound) {
+		t.Errorf("InsertAfter(missing) error = %v, want ErrNotFound", err)
+	}
+	if _, err := l.InsertBefore("b", "zz"); !errors.Is(err, gexorank.ErrNotFound) {
+		t.Errorf("InsertBefore(missing) error = %v, want ErrNotFound", err)
+	}
+	if _, _, err := l.Move("zz", 0); !errors.Is(err, gexorank.ErrNotFound) {
+		t.Errorf("Move(missing) error = %v, want ErrNotFound", err)
+	}
+	if _, _, err := l.Move("a", 1); err == nil {
+		t.Error("Move out of range succeeded, want error")
+	}
+	ra, _ := l.Rank("a")
+	if err := l.Set("b", ra); !errors.Is(err, gexorank.ErrConflict) {
+		t.Errorf("Set(taken rank) error = %v, want ErrConflict", err)
+	}
+	for _, th := range []float64{-0.5, 1.5} {
+		if _, err := gexorank.NewOrderedList[int](nil, th); err == nil {
+			t.Errorf("NewOrderedList(threshold %v) succeeded, want error", th)
+		}
+	}
+}
+
+func TestOrderedList_MoveAndRemove(t *testing.T) {
+	l := newList(t, nil, 0)
+	for i, k := range []string{"a", "b", "c", "d"} {
+		l.InsertAt(i, k)
+	}
+
+	tests := []struct {
+		key   string
+		to    int
+		moved bool
+		want  string
+	}{
+		{"a", 3, true, "b,c,d,a"},
+		{"d", 0, true, "d,b,c,a"},
+		{"c", 1, true, "d,c,b,a"},
+		{"c", 1, false, "d,c,b,a"},
+	}
+	for _, tt := range tests {
+		before, _ := l.Rank(tt.key)
+		r, moved, err := l.Move(tt.key, tt.to)
+		if err != nil {
+			t.Fatalf("Move(%s, %d) error: %v", tt.key, tt.to, err)
+		}
+		if moved != tt.moved || (!moved && r != before) {
+			t.Errorf("Move(%s, %d) = %q, %v; want moved = %v", tt.key, tt.to, r, moved, tt.moved)
+		}
+		if got := keys(t, l); got != tt.want {
+			t.Errorf("Move(%s, %d): list = %q, want %q", tt.key, tt.to, got, tt.want)
+		}
+	}
+
+	if !l.Remove("c") || l.Remove("c") {
+		t.Error("Remove(c) twice: want true, then false")
+	}
+	if got := keys(t, l); got != "d,b,a" || l.Len() != 3 {
+		t.Errorf("after Remove: list = %q, Len() = %d", got, l.Len())
+	}
+}
+
+func TestOrderedList_Set(t *testing.T) {
+	l := newList(t, nil, 0)
+	for k, s := range map[string]string{"c": "0|c", "a": "0|a", "b": "0|b"} {
+		if err := l.Set(k, mustParse(t, s)); err != nil {
+			t.Fatalf("Set(%s) error: %v", k, err)
+		}
+	}
+	if got := keys(t, l); got != "a,b,c" {
+		t.Errorf("list = %q, want a,b,c", got)
+	}
+	if err := l.Set("a", mustParse(t, "0|d")); err != nil {
+		t.Fatalf("Set(a) error: %v", err)
+	}
+	if got := keys(t, l); got != "b,c,a" {
+		t.Errorf("list = %q, want b,c,a", got)
+	}
+}
+
+func TestOrderedList_LocalRebalance(t *testing.T) {
+	rk, err := gexorank.NewRanker(gexorank.WithMaxLength(16))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := newList(t, rk, 0.5) // ranks stay under 8 chars
+	rebalances := 0
+	l.OnRebalance = func(changes []gexorank.RebalanceChange) {
+		rebalances++
+		for _, c := range changes {
+			if k, r := l.At(c.Index); r != c.New {
+				t.Fatalf("change %+v does not match At(%d) = %s %q", c, c.Index, k, r)
+			}
+		}
+	}
+
+	l.InsertAt(0, "first")
+	l.InsertAt(1, "last")
+	for i := range 500 {
+		// Always insert right after the first item: the worst case for
+		// midpoint growth.
+		if _, err := l.InsertAt(1, fmt.Sprint(i)); err != nil {
+			t.Fatalf("insert %d error: %v", i, err)
+		}
+		for _, r := range l.All() {
+			if r.Len() >= 8 {
+				t.Fatalf("insert %d: rank %q has %d chars, want < 8", i, r, r.Len())
+			}
+		}
+	}
+	keys(t, l)
+	if k, _ := l.At(1); k != "499" {
+		t.Errorf("At(1) = %s, want 499", k)
+	}
+	if rebalances == 0 {
+		t.Error("OnRebalance was never called")
+	}
+}
+
+func TestOrderedList_ExhaustedGap(t *testing.T) {
+	rk, err := gexorank.NewRanker(gexorank.WithDefaultLength(2), gexorank.WithMaxLength(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := newList(t, rk, 1)
+	a, _ := rk.Parse("0|a00")
+	b, _ := rk.Parse("0|a01")
+	l.Set("a", a)
+	l.Set("b", b)
+
+	// No 3-char value fits between a00 and a01; the neighbors are respaced.
+	r, err := l.InsertAt(1, "mid")
+	if err != nil {
+		t.Fatalf("InsertAt error: %v", err)
+	}
+	if got := keys(t, l); got != "a,mid,b" {
+		t.Errorf("list = %q, want a,mid,b", got)
+	}
+	if r.Len() > 2 {
+		t.Errorf("rank %q longer than the rebalance limit", r)
+	}
+}
+
+func ExampleOrderedList() {
+	l, _ := gexorank.NewOrderedList[string](nil, 0)
+	l.InsertAt(0, "write docs")
+	l.InsertAt(0, "fix bug")
+	l.InsertAfter("review PR", "fix bug")
+	l.Move("write docs", 0)
+
+	for task, rank := range l.All() {
+		fmt.Println(rank, task)
+	}
+	// Output:
+	// 0|iigiii write docs
+	// 0|iihiii fix bug
+	// 0|iii0ii review PR
+}
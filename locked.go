@@ -0,0 +1,101 @@
+package gexorank
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Tx is a database transaction as used by [InsertLocked]. [*database/sql.Tx]
+// implements it; wrap other handles (such as a GORM transaction) in a small
+// adapter.
+type Tx interface {
+	Commit() error
+	Rollback() error
+}
+
+// BeginFunc starts the transaction of a pessimistic insert.
+type BeginFunc[T Tx] func(ctx context.Context) (T, error)
+
+// LockFunc locks the rows around the insert position inside tx, typically
+// with SELECT … FOR UPDATE, and returns their ranks. Either pointer may be
+// nil (prepend/append). When the list may be empty, lock a parent row as
+// well, since there are no neighbors to lock.
+type LockFunc[T Tx] func(ctx context.Context, tx T) (prev, next *LexoRank, err error)
+
+// LockedInsertFunc persists the new row with the given rank inside tx.
+type LockedInsertFunc[T Tx] func(ctx context.Context, tx T, rank LexoRank) error
+
+// InsertLocked performs the pessimistic insert: it begins a transaction,
+// locks the neighbors, computes a rank via [GenBetween], inserts the row and
+// commits. Concurrent writers block on the lock instead of producing the same
+// rank, so there is nothing to retry; see [InsertBetween] for the optimistic
+// strategy.
+//
+// The transaction is rolled back if lock, GenBetween or insert fails, and if
+// lock or insert panics (the panic is then re-raised). A Rollback error is
+// joined to the original error. A failed Commit is returned as is: the
+// transaction is over either way.
+//
+// Example (database/sql):
+//
+//	rank, err := gexorank.InsertLocked(ctx,
+//	    func(ctx context.Context) (*sql.Tx, error) { return db.BeginTx(ctx, nil) },
+//	    func(ctx context.Context, tx *sql.Tx) (*gexorank.LexoRank, *gexorank.LexoRank, error) {
+//	        // SELECT rank FROM tasks WHERE id IN ($1, $2) FOR UPDATE
+//	    },
+//	    func(ctx context.Context, tx *sql.Tx, rank gexorank.LexoRank) error {
+//	        _, err := tx.ExecContext(ctx, "INSERT INTO tasks (id, rank) VALUES ($1, $2)", id, rank)
+//	        return err
+//	    },
+//	)
+//
+// Because GenBetween falls back to [Default] when there are no neighbors,
+// use [InsertLockedWith] for ranks of a custom [Ranker].
+func InsertLocked[T Tx](ctx context.Context, begin BeginFunc[T], lock LockFunc[T], insert LockedInsertFunc[T]) (LexoRank, error) {
+	return InsertLockedWith(ctx, nil, begin, lock, insert)
+}
+
+// InsertLockedWith is [InsertLocked] with ranks computed by rk's
+// [Ranker.GenBetween], so an insert into an empty list starts at rk's initial
+// rank. A nil rk behaves like InsertLocked.
+func InsertLockedWith[T Tx](ctx context.Context, rk *Ranker, begin BeginFunc[T], lock LockFunc[T], insert LockedInsertFunc[T]) (rank LexoRank, err error) {
+	gen := GenBetween
+	if rk != nil {
+		gen = rk.GenBetween
+	}
+
+	tx, err := begin(ctx)
+	if err != nil {
+		return LexoRank{}, fmt.Errorf("gexorank: begin: %w", err)
+	}
+
+	finished := false
+	defer func() {
+		if finished {
+			return
+		}
+		rbErr := tx.Rollback()
+		if err != nil && rbErr != nil {
+			err = errors.Join(err, fmt.Errorf("gexorank: rollback: %w", rbErr))
+		}
+	}()
+
+	prev, next, err := lock(ctx, tx)
+	if err != nil {
+		return LexoRank{}, fmt.Errorf("gexorank: lock neighbors: %w", err)
+	}
+	rank, err = gen(prev, next)
+	if err != nil {
+		return LexoRank{}, fmt.Errorf("gexorank: gen rank: %w", err)
+	}
+	if err := insert(ctx, tx, rank); err != nil {
+		return LexoRank{}, fmt.Errorf("gexorank: insert: %w", err)
+	}
+
+	finished = true
+	if err := tx.Commit(); err != nil {
+		return LexoRank{}, fmt.Errorf("gexorank: commit: %w", err)
+	}
+	return rank, nil
+}
@@ -0,0 +1,128 @@
+package gexorank_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lupppig/gexorank"
+)
+
+// --- InsertLocked Tests ---
+
+// fakeTx records the calls made on a transaction.
+type fakeTx struct {
+	log         []string
+	rollbackErr error
+	commitErr   error
+}
+
+func (tx *fakeTx) Commit() error {
+	tx.log = append(tx.log, "commit")
+	return tx.commitErr
+}
+
+func (tx *fakeTx) Rollback() error {
+	tx.log = append(tx.log, "rollback")
+	return tx.rollbackErr
+}
+
+func TestInsertLocked(t *testing.T) {
+	a, b := mustParse(t, "0|a"), mustParse(t, "0|c")
+	other := mustParse(t, "1|b")
+	boom := errors.New("boom")
+
+	tests := []struct {
+		name                         string
+		tx                           fakeTx
+		beginErr, lockErr, insertErr error
+		next                         *gexorank.LexoRank
+		wantLog                      string
+		wantErr                      []error // nil when the call succeeds
+	}{
+		{name: "ok", next: &b, wantLog: "lock,insert 0|b,commit"},
+		{name: "begin fails", next: &b, beginErr: boom, wantErr: []error{boom}},
+		{name: "lock fails", next: &b, lockErr: boom, wantLog: "lock,rollback", wantErr: []error{boom}},
+		{name: "gen fails", next: &other, wantLog: "lock,rollback", wantErr: []error{}},
+		{name: "insert fails", next: &b, insertErr: gexorank.ErrConflict, wantLog: "lock,insert 0|b,rollback", wantErr: []error{gexorank.ErrConflict}},
+		{name: "commit fails", next: &b, tx: fakeTx{commitErr: boom}, wantLog: "lock,insert 0|b,commit", wantErr: []error{boom}},
+		{name: "rollback fails", next: &b, tx: fakeTx{rollbackErr: boom}, insertErr: gexorank.ErrConflict, wantLog: "lock,insert 0|b,rollback", wantErr: []error{gexorank.ErrConflict, boom}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := &tt.tx
+			rank, err := gexorank.InsertLocked(context.Background(),
+				func(context.Context) (*fakeTx, error) { return tx, tt.beginErr },
+				func(_ context.Context, tx *fakeTx) (*gexorank.LexoRank, *gexorank.LexoRank, error) {
+					tx.log = append(tx.log, "lock")
+					return &a, tt.next, tt.lockErr
+				},
+				func(_ context.Context, tx *fakeTx, rank gexorank.LexoRank) error {
+					tx.log = append(tx.log, "insert "+rank.String())
+					return tt.insertErr
+				},
+			)
+			if got := strings.Join(tx.log, ","); got != tt.wantLog {
+				t.Errorf("calls = %q, want %q", got, tt.wantLog)
+			}
+			if tt.wantErr == nil {
+				if err != nil || rank.String() != "0|b" {
+					t.Errorf("InsertLocked = %q, %v; want 0|b", rank, err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("InsertLocked = %q, want error", rank)
+			}
+			for _, want := range tt.wantErr {
+				if !errors.Is(err, want) {
+					t.Errorf("error = %v, want it to wrap %v", err, want)
+				}
+			}
+		})
+	}
+}
+
+func TestInsertLocked_Panic(t *testing.T) {
+	tx := &fakeTx{}
+	defer func() {
+		if recover() == nil {
+			t.Error("panic was swallowed")
+		}
+		if got := strings.Join(tx.log, ","); got != "rollback" {
+			t.Errorf("calls = %q, want rollback", got)
+		}
+	}()
+	gexorank.InsertLocked(context.Background(),
+		func(context.Context) (*fakeTx, error) { return tx, nil },
+		func(context.Context, *fakeTx) (*gexorank.LexoRank, *gexorank.LexoRank, error) { return nil, nil, nil },
+		func(context.Context, *fakeTx, gexorank.LexoRank) error { panic("insert") },
+	)
+}
+
+func TestInsertLockedWith(t *testing.T) {
+	rk, err := gexorank.NewRanker(gexorank.WithAlphabet(gexorank.Base62), gexorank.WithDefaultLength(4))
+	if err != nil {
+		t.Fatalf("NewRanker error: %v", err)
+	}
+	tx := &fakeTx{}
+	rank, err := gexorank.InsertLockedWith(context.Background(), rk,
+		func(context.Context) (*fakeTx, error) { return tx, nil },
+		func(context.Context, *fakeTx) (*gexorank.LexoRank, *gexorank.LexoRank, error) { return nil, nil, nil },
+		func(_ context.Context, tx *fakeTx, rank gexorank.LexoRank) error {
+			tx.log = append(tx.log, "insert "+rank.String())
+			return nil
+		},
+	)
+	if err != nil {
+		t.Fatalf("InsertLockedWith error: %v", err)
+	}
+	want := rk.Initial()
+	if rank.String() != want.String() {
+		t.Errorf("first rank of an empty list = %q, want the ranker's Initial %q", rank, want)
+	}
+	if got := strings.Join(tx.log, ","); got != "insert "+want.String()+",commit" {
+		t.Errorf("calls = %q, want insert and commit", got)
+	}
+}
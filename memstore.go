@@ -0,0 +1,254 @@
+package gexorank
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+)
+
+// MemoryStore is a thread-safe, in-memory [RankStore]. It is the reference
+// implementation of the interface, meant for tests, prototypes and lists
+// small enough to keep in memory. It also implements [MigrationStore], so
+// bucket migrations can be exercised against it.
+//
+// The zero value is not usable; create one with [NewMemoryStore].
+type MemoryStore struct {
+	mu        sync.RWMutex
+	ranks     map[string]LexoRank
+	items     []RankedItem // sorted by rank
+	migration MigrationState
+}
+
+var (
+	_ RankStore      = (*MemoryStore)(nil)
+	_ MigrationStore = (*MemoryStore)(nil)
+)
+
+// NewMemoryStore returns an empty MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{ranks: make(map[string]LexoRank)}
+}
+
+// Len returns the number of items in the store.
+func (s *MemoryStore) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.items)
+}
+
+// Get returns the rank of item id, or [ErrNotFound].
+func (s *MemoryStore) Get(_ context.Context, id string) (LexoRank, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r, ok := s.ranks[id]
+	if !ok {
+		return LexoRank{}, fmt.Errorf("%w: %q", ErrNotFound, id)
+	}
+	return r, nil
+}
+
+// Neighbors returns the items immediately before and after item id.
+func (s *MemoryStore) Neighbors(_ context.Context, id string) (prev, next *RankedItem, err error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r, ok := s.ranks[id]
+	if !ok {
+		return nil, nil, fmt.Errorf("%w: %q", ErrNotFound, id)
+	}
+	i, _ := s.search(r)
+	if i > 0 {
+		p := s.items[i-1]
+		prev = &p
+	}
+	if i+1 < len(s.items) {
+		n := s.items[i+1]
+		next = &n
+	}
+	return prev, next, nil
+}
+
+// CompareAndSet sets the rank of item id to rank if its current rank equals
+// old; a zero old inserts a new item.
+func (s *MemoryStore) CompareAndSet(_ context.Context, id string, old, rank LexoRank) error {
+	if isZeroRank(rank) {
+		return fmt.Errorf("gexorank: cannot store zero rank for %q", id)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cur, exists := s.ranks[id]
+	switch {
+	case isZeroRank(old) && exists:
+		return fmt.Errorf("%w: %q already exists", ErrConflict, id)
+	case !isZeroRank(old) && !exists:
+		return fmt.Errorf("%w: %q", ErrNotFound, id)
+	case exists && cur.CompareTo(old) != 0:
+		return fmt.Errorf("%w: %q has rank %s, not %s", ErrConflict, id, cur, old)
+	}
+	if i, found := s.search(rank); found && s.items[i].ID != id {
+		return fmt.Errorf("%w: rank %s is taken by %q", ErrConflict, rank, s.items[i].ID)
+	}
+
+	if exists {
+		i, _ := s.search(cur)
+		s.items = slices.Delete(s.items, i, i+1)
+	}
+	i, _ := s.search(rank)
+	s.items = slices.Insert(s.items, i, RankedItem{ID: id, Rank: rank})
+	s.ranks[id] = rank
+	return nil
+}
+
+// ListRange returns up to limit items ranked strictly between after and
+// before. A limit of zero or less returns every item in the range.
+func (s *MemoryStore) ListRange(_ context.Context, after, before *LexoRank, limit int) ([]RankedItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	start := 0
+	if after != nil {
+		var found bool
+		start, found = s.search(*after)
+		if found {
+			start++
+		}
+	}
+
+	var result []RankedItem
+	for _, it := range s.items[start:] {
+		if before != nil && it.Rank.CompareTo(*before) >= 0 {
+			break
+		}
+		if limit > 0 && len(result) == limit {
+			break
+		}
+		result = append(result, it)
+	}
+	return result, nil
+}
+
+// BulkUpdate sets the rank of every listed item atomically.
+func (s *MemoryStore) BulkUpdate(_ context.Context, items []RankedItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	updated := make(map[string]LexoRank, len(items))
+	for _, it := range items {
+		if _, ok := s.ranks[it.ID]; !ok {
+			return fmt.Errorf("%w: %q", ErrNotFound, it.ID)
+		}
+		if isZeroRank(it.Rank) {
+			return fmt.Errorf("gexorank: cannot store zero rank for %q", it.ID)
+		}
+		updated[it.ID] = it.Rank
+	}
+
+	next := make([]RankedItem, len(s.items))
+	for i, it := range s.items {
+		if r, ok := updated[it.ID]; ok {
+			it.Rank = r
+		}
+		next[i] = it
+	}
+	slices.SortFunc(next, func(a, b RankedItem) int {
+		return a.Rank.CompareTo(b.Rank)
+	})
+	for i := 1; i < len(next); i++ {
+		if next[i].Rank.CompareTo(next[i-1].Rank) == 0 {
+			return fmt.Errorf("%w: %q and %q would share rank %s", ErrConflict, next[i-1].ID, next[i].ID, next[i].Rank)
+		}
+	}
+
+	s.items = next
+	for id, r := range updated {
+		s.ranks[id] = r
+	}
+	return nil
+}
+
+// Delete removes item id. It returns [ErrNotFound] if id does not exist.
+func (s *MemoryStore) Delete(_ context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.ranks[id]
+	if !ok {
+		return fmt.Errorf("%w: %q", ErrNotFound, id)
+	}
+	i, _ := s.search(r)
+	s.items = slices.Delete(s.items, i, i+1)
+	delete(s.ranks, id)
+	return nil
+}
+
+// CountBucket returns the number of items ranked in bucket.
+func (s *MemoryStore) CountBucket(_ context.Context, bucket Bucket) (int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	lo, hi := s.bucketSpan(bucket)
+	return hi - lo, nil
+}
+
+// ListBucket returns up to limit items ranked in bucket, from the lowest or,
+// when desc is true, from the highest.
+func (s *MemoryStore) ListBucket(_ context.Context, bucket Bucket, limit int, desc bool) ([]RankedItem, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	lo, hi := s.bucketSpan(bucket)
+	if limit > 0 && hi-lo > limit {
+		if desc {
+			lo = hi - limit
+		} else {
+			hi = lo + limit
+		}
+	}
+	result := slices.Clone(s.items[lo:hi])
+	if desc {
+		slices.Reverse(result)
+	}
+	return result, nil
+}
+
+// SetRanks implements [MigrationStore] with [MemoryStore.BulkUpdate].
+func (s *MemoryStore) SetRanks(ctx context.Context, items []RankedItem) error {
+	return s.BulkUpdate(ctx, items)
+}
+
+// LoadMigration returns the saved migration state.
+func (s *MemoryStore) LoadMigration(context.Context) (MigrationState, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.migration, nil
+}
+
+// SaveMigration saves the migration state.
+func (s *MemoryStore) SaveMigration(_ context.Context, state MigrationState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.migration = state
+	return nil
+}
+
+// search returns the position of r in s.items and whether an item with an
+// equal rank is there. s.mu must be held.
+func (s *MemoryStore) search(r LexoRank) (int, bool) {
+	return SearchFunc(s.items, r, func(it RankedItem) LexoRank { return it.Rank })
+}
+
+// bucketSpan returns the range of s.items ranked in bucket. s.mu must be held.
+func (s *MemoryStore) bucketSpan(bucket Bucket) (lo, hi int) {
+	lo, _ = slices.BinarySearchFunc(s.items, bucket, func(it RankedItem, b Bucket) int {
+		return cmp.Compare(it.Rank.bucket, b)
+	})
+	hi, _ = slices.BinarySearchFunc(s.items, bucket+1, func(it RankedItem, b Bucket) int {
+		return cmp.Compare(it.Rank.bucket, b)
+	})
+	return lo, hi
+}
+
+// isZeroRank reports whether r is the zero LexoRank, which has no value.
+func isZeroRank(r LexoRank) bool {
+	return r.value.value == ""
+}
@@ -0,0 +1,235 @@
+package gexorank_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/lupppig/gexorank"
+)
+
+// --- MemoryStore Tests ---
+
+// seedStore inserts one item per rank, with IDs "a", "b", ...
+func seedStore(t *testing.T, ranks ...string) *gexorank.MemoryStore {
+	t.Helper()
+	s := gexorank.NewMemoryStore()
+	for i, r := range ranks {
+		id := string(rune('a' + i))
+		if err := s.CompareAndSet(context.Background(), id, gexorank.LexoRank{}, mustParse(t, r)); err != nil {
+			t.Fatalf("seed %s: %v", id, err)
+		}
+	}
+	return s
+}
+
+func ids(items []gexorank.RankedItem) string {
+	var out []byte
+	for _, it := range items {
+		out = append(out, it.ID...)
+	}
+	return string(out)
+}
+
+func TestMemoryStore_Neighbors(t *testing.T) {
+	ctx := context.Background()
+	s := seedStore(t, "0|c", "0|a", "0|b")
+
+	tests := []struct {
+		id, prev, next string
+	}{
+		{"b", "", "c"}, // b has the lowest rank
+		{"c", "b", "a"},
+		{"a", "c", ""},
+	}
+	for _, tt := range tests {
+		prev, next, err := s.Neighbors(ctx, tt.id)
+		if err != nil {
+			t.Fatalf("Neighbors(%s) error: %v", tt.id, err)
+		}
+		var gotPrev, gotNext string
+		if prev != nil {
+			gotPrev = prev.ID
+		}
+		if next != nil {
+			gotNext = next.ID
+		}
+		if gotPrev != tt.prev || gotNext != tt.next {
+			t.Errorf("Neighbors(%s) = %q, %q; want %q, %q", tt.id, gotPrev, gotNext, tt.prev, tt.next)
+		}
+	}
+
+	if _, _, err := s.Neighbors(ctx, "zz"); !errors.Is(err, gexorank.ErrNotFound) {
+		t.Errorf("Neighbors(missing) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestMemoryStore_CompareAndSet(t *testing.T) {
+	ctx := context.Background()
+	s := seedStore(t, "0|a", "0|b")
+	a, b := mustParse(t, "0|a"), mustParse(t, "0|b")
+	c := mustParse(t, "0|c")
+
+	if err := s.CompareAndSet(ctx, "a", b, c); !errors.Is(err, gexorank.ErrConflict) {
+		t.Errorf("stale old: error = %v, want ErrConflict", err)
+	}
+	if err := s.CompareAndSet(ctx, "a", a, b); !errors.Is(err, gexorank.ErrConflict) {
+		t.Errorf("taken rank: error = %v, want ErrConflict", err)
+	}
+	if err := s.CompareAndSet(ctx, "b", gexorank.LexoRank{}, c); !errors.Is(err, gexorank.ErrConflict) {
+		t.Errorf("insert existing: error = %v, want ErrConflict", err)
+	}
+	if err := s.CompareAndSet(ctx, "zz", a, c); !errors.Is(err, gexorank.ErrNotFound) {
+		t.Errorf("missing item: error = %v, want ErrNotFound", err)
+	}
+
+	// a0 equals a under padded comparison, so it matches the current rank.
+	if err := s.CompareAndSet(ctx, "a", mustParse(t, "0|a0"), c); err != nil {
+		t.Fatalf("CompareAndSet error: %v", err)
+	}
+	if got, _ := s.Get(ctx, "a"); got.String() != "0|c" {
+		t.Errorf("Get(a) = %q, want 0|c", got)
+	}
+	all, _ := s.ListRange(ctx, nil, nil, 0)
+	if ids(all) != "ba" {
+		t.Errorf("order = %q, want ba", ids(all))
+	}
+}
+
+func TestMemoryStore_ListRange(t *testing.T) {
+	ctx := context.Background()
+	s := seedStore(t, "0|a", "0|b", "0|c", "0|d", "1|a")
+	b, d := mustParse(t, "0|b"), mustParse(t, "0|d")
+	between := mustParse(t, "0|bb")
+
+	tests := []struct {
+		name          string
+		after, before *gexorank.LexoRank
+		limit         int
+		want          string
+	}{
+		{"all", nil, nil, 0, "abcde"},
+		{"limited", nil, nil, 2, "ab"},
+		{"exclusive bounds", &b, &d, 0, "c"},
+		{"bound not stored", &between, nil, 0, "cde"},
+		{"open before", nil, &b, 0, "a"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.ListRange(ctx, tt.after, tt.before, tt.limit)
+			if err != nil {
+				t.Fatalf("ListRange error: %v", err)
+			}
+			if ids(got) != tt.want {
+				t.Errorf("ListRange = %q, want %q", ids(got), tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryStore_BulkUpdate(t *testing.T) {
+	ctx := context.Background()
+	s := seedStore(t, "0|a", "0|b", "0|c")
+
+	// Swapping two ranks is valid as a whole, though not item by item.
+	err := s.BulkUpdate(ctx, []gexorank.RankedItem{
+		{ID: "a", Rank: mustParse(t, "0|c")},
+		{ID: "c", Rank: mustParse(t, "0|a")},
+	})
+	if err != nil {
+		t.Fatalf("BulkUpdate error: %v", err)
+	}
+	all, _ := s.ListRange(ctx, nil, nil, 0)
+	if ids(all) != "cba" {
+		t.Errorf("order = %q, want cba", ids(all))
+	}
+
+	err = s.BulkUpdate(ctx, []gexorank.RankedItem{
+		{ID: "a", Rank: mustParse(t, "0|z")},
+		{ID: "b", Rank: mustParse(t, "0|a")},
+	})
+	if !errors.Is(err, gexorank.ErrConflict) {
+		t.Errorf("duplicate result: error = %v, want ErrConflict", err)
+	}
+	err = s.BulkUpdate(ctx, []gexorank.RankedItem{
+		{ID: "a", Rank: mustParse(t, "0|z")},
+		{ID: "zz", Rank: mustParse(t, "0|y")},
+	})
+	if !errors.Is(err, gexorank.ErrNotFound) {
+		t.Errorf("missing item: error = %v, want ErrNotFound", err)
+	}
+	if got, _ := s.Get(ctx, "a"); got.String() != "0|c" {
+		t.Errorf("failed BulkUpdate changed a to %q", got)
+	}
+}
+
+func TestMemoryStore_Delete(t *testing.T) {
+	ctx := context.Background()
+	s := seedStore(t, "0|a", "0|b")
+	if err := s.Delete(ctx, "a"); err != nil {
+		t.Fatalf("Delete error: %v", err)
+	}
+	if _, err := s.Get(ctx, "a"); !errors.Is(err, gexorank.ErrNotFound) {
+		t.Errorf("Get after Delete error = %v, want ErrNotFound", err)
+	}
+	if err := s.Delete(ctx, "a"); !errors.Is(err, gexorank.ErrNotFound) {
+		t.Errorf("second Delete error = %v, want ErrNotFound", err)
+	}
+	if s.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", s.Len())
+	}
+}
+
+func TestMemoryStore_ConcurrentInserts(t *testing.T) {
+	ctx := context.Background()
+	s := seedStore(t, "0|a", "0|z")
+	first, last := mustParse(t, "0|a"), mustParse(t, "0|z")
+
+	// Every writer computes the same midpoint; exactly one insert may win.
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	wins := 0
+	for i := range 16 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			mid, _ := gexorank.Between(first, last)
+			err := s.CompareAndSet(ctx, fmt.Sprintf("w%d", i), gexorank.LexoRank{}, mid)
+			if err == nil {
+				mu.Lock()
+				wins++
+				mu.Unlock()
+			} else if !errors.Is(err, gexorank.ErrConflict) {
+				t.Errorf("CompareAndSet error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if wins != 1 || s.Len() != 3 {
+		t.Errorf("wins = %d, Len() = %d; want 1 and 3", wins, s.Len())
+	}
+}
+
+func TestMemoryStore_Migration(t *testing.T) {
+	ctx := context.Background()
+	s := gexorank.NewMemoryStore()
+	ranks := longRanks(t, gexorank.Bucket0, 30)
+	for i, r := range ranks {
+		if err := s.CompareAndSet(ctx, fmt.Sprint(i), gexorank.LexoRank{}, mustParse(t, r)); err != nil {
+			t.Fatalf("seed: %v", err)
+		}
+	}
+
+	m := gexorank.NewMigration(s, nil, 7)
+	if err := m.Run(ctx); err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+	all, _ := s.ListRange(ctx, nil, nil, 0)
+	for i, it := range all {
+		if it.ID != fmt.Sprint(i) || it.Rank.Bucket() != gexorank.Bucket1 {
+			t.Fatalf("all[%d] = %s %q, want %d in bucket 1", i, it.ID, it.Rank, i)
+		}
+	}
+}
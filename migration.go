@@ -0,0 +1,429 @@
+package gexorank
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+)
+
+// RankedItem is an item identified by ID together with its rank.
+type RankedItem struct {
+	ID   string
+	Rank LexoRank
+}
+
+// MigrationPhase is a step of the bucket rotation driven by [Migration].
+type MigrationPhase uint8
+
+const (
+	// PhaseIdle means no migration is in progress. All ranks live in the
+	// authoritative bucket.
+	PhaseIdle MigrationPhase = iota
+	// PhasePlanned means the source bucket has been counted and the spacing
+	// of the new ranks fixed, but no rank has been rewritten yet.
+	PhasePlanned
+	// PhaseCopying means items are being rewritten into the target bucket in
+	// batches. The list is split across both buckets.
+	PhaseCopying
+	// PhaseDualRead means every item has been rewritten, but readers still
+	// read both buckets so that late writes into the source are not lost.
+	PhaseDualRead
+	// PhaseCutOver means the target bucket has become authoritative. New
+	// ranks are generated there.
+	PhaseCutOver
+	// PhaseCleanup means stragglers left in the source bucket are being
+	// swept into the target before the migration completes.
+	PhaseCleanup
+)
+
+// String returns the lower-case name of the phase, e.g. "copying".
+func (p MigrationPhase) String() string {
+	switch p {
+	case PhaseIdle:
+		return "idle"
+	case PhasePlanned:
+		return "planned"
+	case PhaseCopying:
+		return "copying"
+	case PhaseDualRead:
+		return "dual-read"
+	case PhaseCutOver:
+		return "cut-over"
+	case PhaseCleanup:
+		return "cleanup"
+	default:
+		return fmt.Sprintf("MigrationPhase(%d)", uint8(p))
+	}
+}
+
+// MigrationState is the persisted progress of a [Migration]. Outside a
+// migration only From is meaningful: it is the authoritative bucket.
+type MigrationState struct {
+	Phase  MigrationPhase
+	From   Bucket
+	To     Bucket
+	Total  int // items in From when the migration was planned
+	Copied int // items rewritten into To so far
+}
+
+// MigrationStore is the storage a [Migration] runs against. Each item has a
+// single rank, and the bucket prefix of that rank determines which bucket it
+// is in; the store must order ranks with [LexoRank.CompareTo] (or byte-wise,
+// which is equivalent for canonical values).
+type MigrationStore interface {
+	// CountBucket returns the number of items ranked in bucket.
+	CountBucket(ctx context.Context, bucket Bucket) (int, error)
+	// ListBucket returns up to limit items ranked in bucket, ordered by rank
+	// starting from the lowest, or from the highest when desc is true.
+	ListBucket(ctx context.Context, bucket Bucket, limit int, desc bool) ([]RankedItem, error)
+	// SetRanks stores the given rank for each item, moving it to the
+	// rank's bucket. The update should be atomic.
+	SetRanks(ctx context.Context, items []RankedItem) error
+	// LoadMigration returns the saved state, or the zero state if none was
+	// ever saved.
+	LoadMigration(ctx context.Context) (MigrationState, error)
+	// SaveMigration persists the state.
+	SaveMigration(ctx context.Context, state MigrationState) error
+}
+
+// DefaultMigrationBatch is the number of items a [Migration] rewrites per
+// batch when no batch size is given.
+const DefaultMigrationBatch = 1000
+
+// Migration coordinates the three-bucket rotation: it rebalances every item
+// from the authoritative bucket into [Bucket.Next] without stopping reads.
+//
+// Items are rewritten in place, starting from the end of the list that sorts
+// next to the target bucket (the last item when moving 0→1 or 1→2, the first
+// when moving 2→0). Because every rank in one bucket sorts before every rank
+// in the next, ordering by rank across both buckets yields the correct list
+// at every moment. Readers query [Migration.ReadBuckets], and writers that
+// insert between neighbors in different buckets use [Migration.GenBetween].
+//
+// Progress is saved after every batch, and the position of the next batch is
+// derived from the store rather than from a counter, so a crashed migration
+// resumes safely with [Migration.Load] followed by [Migration.Run].
+//
+// A Migration is safe for concurrent use. Only one coordinator should drive a
+// given store at a time.
+type Migration struct {
+	store MigrationStore
+	rk    *Ranker
+	batch int
+
+	mu    sync.Mutex
+	state MigrationState
+}
+
+// NewMigration creates a Migration over store. New ranks are generated with
+// rk (nil for [Default]); batchSize items are rewritten per batch (values
+// below 1 select [DefaultMigrationBatch]). Call [Migration.Load] to pick up
+// saved state before driving it.
+func NewMigration(store MigrationStore, rk *Ranker, batchSize int) *Migration {
+	if rk == nil {
+		rk = defaultRanker
+	}
+	if batchSize < 1 {
+		batchSize = DefaultMigrationBatch
+	}
+	return &Migration{store: store, rk: rk, batch: batchSize}
+}
+
+// Load reads the saved state from the store.
+func (m *Migration) Load(ctx context.Context) error {
+	state, err := m.store.LoadMigration(ctx)
+	if err != nil {
+		return fmt.Errorf("gexorank: load migration: %w", err)
+	}
+	m.mu.Lock()
+	m.state = state
+	m.mu.Unlock()
+	return nil
+}
+
+// State returns a snapshot of the current progress.
+func (m *Migration) State() MigrationState {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.state
+}
+
+// Phase returns the current phase.
+func (m *Migration) Phase() MigrationPhase {
+	return m.State().Phase
+}
+
+// Authoritative returns the bucket new ranks belong to: the source bucket
+// until the cut-over, and the target bucket from then on.
+func (m *Migration) Authoritative() Bucket {
+	s := m.State()
+	if s.Phase >= PhaseCutOver {
+		return s.To
+	}
+	return s.From
+}
+
+// ReadBuckets returns the buckets readers must query (ordering the combined
+// result by rank). Outside a migration that is only the authoritative bucket.
+func (m *Migration) ReadBuckets() []Bucket {
+	s := m.State()
+	if s.Phase == PhaseIdle || s.Phase == PhasePlanned {
+		return []Bucket{s.From}
+	}
+	return []Bucket{s.From, s.To}
+}
+
+// Plan starts a migration out of the authoritative bucket into the next one
+// in the rotation. It counts the items to fix the spacing of the new ranks.
+func (m *Migration) Plan(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.state.Phase != PhaseIdle {
+		return fmt.Errorf("gexorank: cannot plan migration in phase %s", m.state.Phase)
+	}
+	from := m.state.From
+	total, err := m.store.CountBucket(ctx, from)
+	if err != nil {
+		return fmt.Errorf("gexorank: count bucket %s: %w", from, err)
+	}
+	return m.save(ctx, MigrationState{
+		Phase: PhasePlanned,
+		From:  from,
+		To:    m.rk.NextBucket(from),
+		Total: total,
+	})
+}
+
+// Step performs one unit of work and persists the result: it rewrites one
+// batch while copying, or advances to the next phase. It reports whether the
+// migration has completed. Callers that need a grace period before the
+// cut-over (for in-flight writes to drain) can call Step until
+// [Migration.Phase] reaches [PhaseDualRead] and wait there.
+func (m *Migration) Step(ctx context.Context) (done bool, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	s := m.state
+	switch s.Phase {
+	case PhaseIdle:
+		return true, nil
+
+	case PhasePlanned:
+		s.Phase = PhaseCopying
+		return false, m.save(ctx, s)
+
+	case PhaseCopying:
+		n, err := m.copyBatch(ctx)
+		if err != nil {
+			return false, err
+		}
+		s.Copied += n
+		if n == 0 {
+			s.Phase = PhaseDualRead
+		}
+		return false, m.save(ctx, s)
+
+	case PhaseDualRead:
+		// Writes that raced the last batch may have landed in the source.
+		left, err := m.store.CountBucket(ctx, s.From)
+		if err != nil {
+			return false, fmt.Errorf("gexorank: count bucket %s: %w", s.From, err)
+		}
+		if left > 0 {
+			s.Phase = PhaseCopying
+		} else {
+			s.Phase = PhaseCutOver
+		}
+		return false, m.save(ctx, s)
+
+	case PhaseCutOver:
+		s.Phase = PhaseCleanup
+		return false, m.save(ctx, s)
+
+	case PhaseCleanup:
+		n, err := m.copyBatch(ctx)
+		if err != nil {
+			return false, err
+		}
+		if n > 0 {
+			s.Copied += n
+			return false, m.save(ctx, s)
+		}
+		return true, m.save(ctx, MigrationState{Phase: PhaseIdle, From: s.To})
+
+	default:
+		return false, fmt.Errorf("gexorank: unknown migration phase %s", s.Phase)
+	}
+}
+
+// Run drives the migration to completion, starting with [Migration.Plan] if
+// none is in progress. It stops early if ctx is cancelled; the saved progress
+// lets a later Run resume.
+func (m *Migration) Run(ctx context.Context) error {
+	if m.Phase() == PhaseIdle {
+		if err := m.Plan(ctx); err != nil {
+			return err
+		}
+	}
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		done, err := m.Step(ctx)
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+	}
+}
+
+// GenBetween returns a rank between prev and next that respects the
+// migration. Neighbors in the same bucket are handled by [Ranker.GenBetween].
+// When the neighbors straddle the boundary between the source and target
+// buckets, the new rank is placed next to the neighbor in the authoritative
+// bucket, so it sorts correctly and, before the cut-over, is picked up by a
+// later batch.
+func (m *Migration) GenBetween(prev, next *LexoRank) (LexoRank, error) {
+	if prev == nil || next == nil || prev.bucket == next.bucket {
+		return m.rk.GenBetween(prev, next)
+	}
+
+	auth := m.Authoritative()
+	var r, anchor LexoRank
+	switch auth {
+	case prev.bucket:
+		anchor = *prev
+		r = m.rk.genNext(anchor)
+	case next.bucket:
+		anchor = *next
+		r = m.rk.genPrev(anchor)
+	default:
+		return LexoRank{}, fmt.Errorf("gexorank: neither %s nor %s is in authoritative bucket %s", prev, next, auth)
+	}
+	if r.CompareTo(anchor) == 0 {
+		return LexoRank{}, ErrRankExhausted
+	}
+	return r, nil
+}
+
+// save persists s and makes it current. m.mu must be held.
+func (m *Migration) save(ctx context.Context, s MigrationState) error {
+	if err := m.store.SaveMigration(ctx, s); err != nil {
+		return fmt.Errorf("gexorank: save migration: %w", err)
+	}
+	m.state = s
+	return nil
+}
+
+// ascending reports whether the target bucket sorts before the source, in
+// which case items are copied from the start of the list.
+func (s MigrationState) ascending() bool {
+	return s.To < s.From
+}
+
+// copyBatch rewrites the batch of source items next to the target bucket and
+// returns how many it moved. m.mu must be held.
+func (m *Migration) copyBatch(ctx context.Context) (int, error) {
+	s := m.state
+	asc := s.ascending()
+
+	items, err := m.store.ListBucket(ctx, s.From, m.batch, !asc)
+	if err != nil {
+		return 0, fmt.Errorf("gexorank: list bucket %s: %w", s.From, err)
+	}
+	if len(items) == 0 {
+		return 0, nil
+	}
+
+	// The boundary is the target rank closest to the source, if any.
+	edge, err := m.store.ListBucket(ctx, s.To, 1, asc)
+	if err != nil {
+		return 0, fmt.Errorf("gexorank: list bucket %s: %w", s.To, err)
+	}
+	var boundary *LexoRank
+	if len(edge) > 0 {
+		boundary = &edge[0].Rank
+	}
+
+	ranks, err := m.place(boundary, len(items), asc)
+	if err != nil {
+		return 0, err
+	}
+	for i := range items {
+		items[i].Rank = ranks[i]
+	}
+	if err := m.store.SetRanks(ctx, items); err != nil {
+		return 0, fmt.Errorf("gexorank: set ranks: %w", err)
+	}
+	return len(items), nil
+}
+
+// place returns k target ranks continuing away from boundary (nil when the
+// target is still empty): ascending above it, or descending below it. They
+// use the spacing planned for the whole migration, falling back to spreading
+// the batch over the remaining space once that spacing runs out.
+func (m *Migration) place(boundary *LexoRank, k int, asc bool) ([]LexoRank, error) {
+	s := m.state
+	rb, err := m.rk.newRebalancer(max(s.Total, 1), s.To)
+	if errors.Is(err, ErrRankExhausted) {
+		// The planned spacing would be zero; spread this batch instead.
+		return m.spreadBatch(boundary, k, asc)
+	}
+	if err != nil {
+		return nil, err
+	}
+	set := m.rk.alpha.set
+	limit := new(big.Int).Exp(big.NewInt(int64(set.Size())), big.NewInt(int64(rb.length)), nil)
+
+	start := new(big.Int)
+	switch {
+	case boundary != nil:
+		start = floorAt(set, boundary.value.value, rb.length)
+	case !asc:
+		start.Set(limit)
+	}
+
+	ranks := make([]LexoRank, k)
+	offset := new(big.Int)
+	for i := range ranks {
+		offset.Add(offset, rb.step)
+		v := new(big.Int)
+		if asc {
+			v.Add(start, offset)
+		} else {
+			v.Sub(start, offset)
+		}
+		if v.Sign() <= 0 || v.Cmp(limit) >= 0 {
+			return m.spreadBatch(boundary, k, asc)
+		}
+		ranks[i] = m.rk.rank(s.To, newRankValue(canonicalStr(set, bigIntToStr(set, v, rb.length)), m.rk.alpha))
+	}
+	return ranks, nil
+}
+
+// spreadBatch places k ranks evenly between boundary and the end of the
+// target bucket, in batch order.
+func (m *Migration) spreadBatch(boundary *LexoRank, k int, asc bool) ([]LexoRank, error) {
+	var ranks []LexoRank
+	var err error
+	if asc {
+		ranks, err = m.rk.BetweenN(boundary, nil, k)
+	} else {
+		ranks, err = m.rk.BetweenN(nil, boundary, k)
+		for i, j := 0, len(ranks)-1; i < j; i, j = i+1, j-1 {
+			ranks[i], ranks[j] = ranks[j], ranks[i]
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	for i := range ranks {
+		ranks[i].bucket = m.state.To
+	}
+	return ranks, nil
+}
@@ -0,0 +1,351 @@
+package gexorank_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/lupppig/gexorank"
+)
+
+// --- Migration Tests ---
+
+// migrationStore is a map-backed MigrationStore for tests. failSets makes
+// the next n SetRanks calls fail.
+type migrationStore struct {
+	mu       sync.Mutex
+	ranks    map[string]gexorank.LexoRank
+	state    gexorank.MigrationState
+	failSets int
+}
+
+func newMigrationStore(t *testing.T, ranks ...string) *migrationStore {
+	t.Helper()
+	s := &migrationStore{ranks: make(map[string]gexorank.LexoRank)}
+	for i, r := range ranks {
+		s.ranks[fmt.Sprintf("item-%02d", i)] = mustParse(t, r)
+	}
+	return s
+}
+
+func (s *migrationStore) sorted() []gexorank.RankedItem {
+	var items []gexorank.RankedItem
+	for id, r := range s.ranks {
+		items = append(items, gexorank.RankedItem{ID: id, Rank: r})
+	}
+	slices.SortFunc(items, func(a, b gexorank.RankedItem) int {
+		if a.Rank.Bucket() != b.Rank.Bucket() {
+			return int(a.Rank.Bucket()) - int(b.Rank.Bucket())
+		}
+		return a.Rank.CompareTo(b.Rank)
+	})
+	return items
+}
+
+// order returns item IDs in list order, which spans buckets during a migration.
+func (s *migrationStore) order() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []string
+	for _, it := range s.sorted() {
+		ids = append(ids, it.ID)
+	}
+	return ids
+}
+
+func (s *migrationStore) CountBucket(_ context.Context, b gexorank.Bucket) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, r := range s.ranks {
+		if r.Bucket() == b {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *migrationStore) ListBucket(_ context.Context, b gexorank.Bucket, limit int, desc bool) ([]gexorank.RankedItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var items []gexorank.RankedItem
+	for _, it := range s.sorted() {
+		if it.Rank.Bucket() == b {
+			items = append(items, it)
+		}
+	}
+	if desc {
+		slices.Reverse(items)
+	}
+	return items[:min(limit, len(items))], nil
+}
+
+func (s *migrationStore) SetRanks(_ context.Context, items []gexorank.RankedItem) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.failSets > 0 {
+		s.failSets--
+		return errors.New("connection reset")
+	}
+	for _, it := range items {
+		s.ranks[it.ID] = it.Rank
+	}
+	return nil
+}
+
+func (s *migrationStore) LoadMigration(context.Context) (gexorank.MigrationState, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state, nil
+}
+
+func (s *migrationStore) SaveMigration(_ context.Context, state gexorank.MigrationState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state = state
+	return nil
+}
+
+func (s *migrationStore) insert(id string, r gexorank.LexoRank) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.ranks[id] = r
+}
+
+// longRanks returns n ascending ranks in bucket b that have grown long.
+func longRanks(t *testing.T, b gexorank.Bucket, n int) []string {
+	t.Helper()
+	lo := mustParse(t, b.String()+"|i")
+	hi := mustParse(t, b.String()+"|i1")
+	var out []string
+	for range n {
+		mid, err := gexorank.Between(lo, hi)
+		if err != nil {
+			t.Fatalf("Between error: %v", err)
+		}
+		out = append(out, mid.String())
+		lo = mid
+	}
+	return out
+}
+
+func TestMigration_Run(t *testing.T) {
+	for _, from := range []gexorank.Bucket{gexorank.Bucket0, gexorank.Bucket1, gexorank.Bucket2} {
+		t.Run("from "+from.String(), func(t *testing.T) {
+			ctx := context.Background()
+			store := newMigrationStore(t, longRanks(t, from, 25)...)
+			store.state.From = from
+			want := store.order()
+
+			m := gexorank.NewMigration(store, nil, 4)
+			if err := m.Load(ctx); err != nil {
+				t.Fatalf("Load error: %v", err)
+			}
+			if err := m.Run(ctx); err != nil {
+				t.Fatalf("Run error: %v", err)
+			}
+
+			if got := store.order(); !slices.Equal(got, want) {
+				t.Errorf("order after migration = %v, want %v", got, want)
+			}
+			for id, r := range store.ranks {
+				if r.Bucket() != from.Next() || r.Len() > gexorank.DefaultLength {
+					t.Errorf("%s = %q, want a short rank in bucket %s", id, r, from.Next())
+				}
+			}
+			if s := m.State(); s.Phase != gexorank.PhaseIdle || s.From != from.Next() {
+				t.Errorf("final state = %+v, want idle with From %s", s, from.Next())
+			}
+			if m.Authoritative() != from.Next() {
+				t.Errorf("Authoritative() = %s, want %s", m.Authoritative(), from.Next())
+			}
+		})
+	}
+}
+
+func TestMigration_Phases(t *testing.T) {
+	ctx := context.Background()
+	store := newMigrationStore(t, longRanks(t, gexorank.Bucket0, 3)...)
+	m := gexorank.NewMigration(store, nil, 2)
+
+	want := []struct {
+		phase gexorank.MigrationPhase
+		auth  gexorank.Bucket
+		reads int
+	}{
+		{gexorank.PhasePlanned, 0, 1},
+		{gexorank.PhaseCopying, 0, 2},
+		{gexorank.PhaseCopying, 0, 2}, // first batch of 2
+		{gexorank.PhaseCopying, 0, 2}, // last item
+		{gexorank.PhaseDualRead, 0, 2},
+		{gexorank.PhaseCutOver, 1, 2},
+		{gexorank.PhaseCleanup, 1, 2},
+		{gexorank.PhaseIdle, 1, 1},
+	}
+
+	if err := m.Plan(ctx); err != nil {
+		t.Fatalf("Plan error: %v", err)
+	}
+	for i, w := range want {
+		if m.Phase() != w.phase || m.Authoritative() != w.auth || len(m.ReadBuckets()) != w.reads {
+			t.Fatalf("step %d: phase %s, authoritative %s, reads %v; want %s, %s, %d buckets",
+				i, m.Phase(), m.Authoritative(), m.ReadBuckets(), w.phase, w.auth, w.reads)
+		}
+		if i == len(want)-1 {
+			break
+		}
+		if _, err := m.Step(ctx); err != nil {
+			t.Fatalf("step %d: %v", i, err)
+		}
+	}
+	if err := m.Plan(ctx); err != nil {
+		t.Errorf("Plan after completion should start the next rotation: %v", err)
+	}
+}
+
+func TestMigration_Resume(t *testing.T) {
+	ctx := context.Background()
+	store := newMigrationStore(t, longRanks(t, gexorank.Bucket0, 20)...)
+	want := store.order()
+
+	m := gexorank.NewMigration(store, nil, 3)
+	if err := m.Plan(ctx); err != nil {
+		t.Fatalf("Plan error: %v", err)
+	}
+	for range 4 {
+		if _, err := m.Step(ctx); err != nil {
+			t.Fatalf("Step error: %v", err)
+		}
+	}
+
+	// The coordinator crashes mid-copy; a new one picks up the saved state.
+	store.failSets = 1
+	if err := m.Run(ctx); err == nil {
+		t.Fatal("Run should fail while the store is failing")
+	}
+	if got := store.order(); !slices.Equal(got, want) {
+		t.Fatalf("order after crash = %v, want %v", got, want)
+	}
+
+	resumed := gexorank.NewMigration(store, nil, 3)
+	if err := resumed.Load(ctx); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if resumed.Phase() != gexorank.PhaseCopying {
+		t.Fatalf("resumed phase = %s, want copying", resumed.Phase())
+	}
+	if err := resumed.Run(ctx); err != nil {
+		t.Fatalf("Run error: %v", err)
+	}
+	if got := store.order(); !slices.Equal(got, want) {
+		t.Errorf("order after resume = %v, want %v", got, want)
+	}
+	if n, _ := store.CountBucket(ctx, gexorank.Bucket0); n != 0 {
+		t.Errorf("%d items left in bucket 0", n)
+	}
+}
+
+func TestMigration_WritesDuringCopy(t *testing.T) {
+	for _, from := range []gexorank.Bucket{gexorank.Bucket0, gexorank.Bucket2} {
+		t.Run("from "+from.String(), func(t *testing.T) {
+			ctx := context.Background()
+			store := newMigrationStore(t, longRanks(t, from, 10)...)
+			store.state.From = from
+			m := gexorank.NewMigration(store, nil, 2)
+			if err := m.Load(ctx); err != nil {
+				t.Fatalf("Load error: %v", err)
+			}
+			if err := m.Plan(ctx); err != nil {
+				t.Fatalf("Plan error: %v", err)
+			}
+
+			// Insert between every fourth pair after each step, so some
+			// inserts straddle the bucket boundary and the total outgrows
+			// the plan.
+			for i := 0; m.Phase() != gexorank.PhaseIdle; i++ {
+				if _, err := m.Step(ctx); err != nil {
+					t.Fatalf("Step error: %v", err)
+				}
+				order := store.order()
+				if i >= 12 || len(order) < 2 {
+					continue
+				}
+				for j := 0; j+1 < len(order); j += 4 {
+					prev, next := store.ranks[order[j]], store.ranks[order[j+1]]
+					r, err := m.GenBetween(&prev, &next)
+					if err != nil {
+						t.Fatalf("GenBetween(%q, %q) error: %v", prev, next, err)
+					}
+					id := fmt.Sprintf("new-%02d-%02d", i, j)
+					store.insert(id, r)
+					want := slices.Insert(order, j+1, id)
+					if got := store.order(); !slices.Equal(got, want) {
+						t.Fatalf("insert %s at %q misplaced: order %v, want %v", id, r, got, want)
+					}
+					order = want
+				}
+			}
+
+			for id, r := range store.ranks {
+				if r.Bucket() != from.Next() {
+					t.Errorf("%s = %q left outside bucket %s", id, r, from.Next())
+				}
+			}
+		})
+	}
+}
+
+func ExampleMigration() {
+	ctx := context.Background()
+	store := newExampleStore()
+
+	m := gexorank.NewMigration(store, nil, 100)
+	if err := m.Load(ctx); err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Println("reading", m.ReadBuckets())
+	if err := m.Run(ctx); err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Println("authoritative", m.Authoritative())
+	for _, it := range store.sorted() {
+		fmt.Println(it.ID, it.Rank)
+	}
+	// Output:
+	// reading [0]
+	// authoritative 1
+	// a 1|900003
+	// b 1|i00002
+	// c 1|r00001
+}
+
+func newExampleStore() *migrationStore {
+	s := &migrationStore{ranks: make(map[string]gexorank.LexoRank)}
+	for id, r := range map[string]string{"a": "0|i0000001", "b": "0|i0000002", "c": "0|i00000021"} {
+		s.ranks[id], _ = gexorank.Parse(r)
+	}
+	return s
+}
+
+func TestMigration_Exhausted(t *testing.T) {
+	// 40 items cannot be spaced out within one base36 character.
+	rk, err := gexorank.NewRanker(gexorank.WithDefaultLength(1), gexorank.WithMaxLength(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	store := newMigrationStore(t, longRanks(t, gexorank.Bucket0, 40)...)
+
+	m := gexorank.NewMigration(store, rk, 8)
+	if err := m.Load(ctx); err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if err := m.Run(ctx); !errors.Is(err, gexorank.ErrRankExhausted) {
+		t.Errorf("Run error = %v, want ErrRankExhausted", err)
+	}
+}
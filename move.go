@@ -0,0 +1,92 @@
+package gexorank
+
+import "fmt"
+
+// Move computes a new rank for an existing item so that it sorts between
+// prev and next, its neighbors at the target position. Either neighbor may be
+// nil when the target is the start or end of the list.
+//
+// If the item already sorts between prev and next, it is in place: Move
+// returns item unchanged and moved == false, and nothing needs to be written.
+// This covers moving an item to its current position and the degenerate
+// targets where the item is itself one of the neighbors ("put it after
+// itself"). Otherwise the new rank is computed with [GenBetween] and moved is
+// true.
+//
+// The neighbors must be in order; prev equal to next is only accepted when
+// both are the item. Neighbors are compared as ranks, so when only IDs are
+// known, pass the stored ranks of the items at the target position.
+func Move(item LexoRank, prev, next *LexoRank) (rank LexoRank, moved bool, err error) {
+	return item.rk().Move(item, prev, next)
+}
+
+// Move computes a new rank for item between prev and next using this
+// Ranker's alphabet and limits. See the package-level [Move].
+func (rk *Ranker) Move(item LexoRank, prev, next *LexoRank) (rank LexoRank, moved bool, err error) {
+	if prev != nil && next != nil {
+		if c := prev.CompareTo(*next); c > 0 || (c == 0 && prev.CompareTo(item) != 0) {
+			return LexoRank{}, false, fmt.Errorf("gexorank: cannot move between %s and %s: neighbors are not in order", prev, next)
+		}
+	}
+	if (prev == nil || prev.CompareTo(item) <= 0) && (next == nil || item.CompareTo(*next) <= 0) {
+		return item, false, nil
+	}
+
+	rank, err = rk.GenBetween(prev, next)
+	if err != nil {
+		return LexoRank{}, false, err
+	}
+	return rank, true, nil
+}
+
+// MoveN computes new ranks for a multi-selection dropped between prev and
+// next. items are the ranks of the moving items in their current order; the
+// result holds one new rank per item, in the same order, evenly spaced across
+// the gap at the shortest length that fits (see [BetweenN]). Use it instead
+// of calling [Move] once per item, which makes the ranks grow with every
+// item.
+//
+// prev and next are the destination neighbors once the selection is taken
+// out, so neither may be one of the moving items. Either may be nil when the
+// selection is dropped at the start or end of the list. If the ranks do not
+// fit within the max length, [ErrRankExhausted] is returned.
+func MoveN(items []LexoRank, prev, next *LexoRank) ([]LexoRank, error) {
+	rk := rankerOf(prev, next)
+	if prev == nil && next == nil && len(items) > 0 {
+		rk = items[0].rk()
+	}
+	return rk.MoveN(items, prev, next)
+}
+
+// MoveN computes new ranks for items between prev and next using this
+// Ranker's alphabet and limits. See the package-level [MoveN].
+func (rk *Ranker) MoveN(items []LexoRank, prev, next *LexoRank) ([]LexoRank, error) {
+	if len(items) == 0 {
+		return nil, nil
+	}
+	for i, r := range items {
+		if i > 0 && r.CompareTo(items[i-1]) <= 0 {
+			return nil, fmt.Errorf("gexorank: items are not in order: %s at index %d follows %s", r, i, items[i-1])
+		}
+		for _, n := range []*LexoRank{prev, next} {
+			if n != nil && n.CompareTo(r) == 0 {
+				return nil, fmt.Errorf("gexorank: neighbor %s is one of the moving items", n)
+			}
+		}
+	}
+	if prev != nil && next != nil && prev.CompareTo(*next) >= 0 {
+		return nil, fmt.Errorf("gexorank: cannot move between %s and %s: neighbors are not in order", prev, next)
+	}
+
+	result, err := rk.BetweenN(prev, next, len(items))
+	if err != nil {
+		return nil, err
+	}
+	if prev == nil && next == nil {
+		// The selection is the whole list; keep it in its bucket.
+		for i := range result {
+			result[i].bucket = items[0].bucket
+		}
+	}
+	return result, nil
+}
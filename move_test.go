@@ -0,0 +1,222 @@
+package gexorank_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/lupppig/gexorank"
+)
+
+// --- Move Tests ---
+
+func TestMove(t *testing.T) {
+	a, b, c, d := mustParse(t, "0|a"), mustParse(t, "0|b"), mustParse(t, "0|c"), mustParse(t, "0|d")
+	other := mustParse(t, "1|b")
+
+	tests := []struct {
+		name       string
+		item       gexorank.LexoRank
+		prev, next *gexorank.LexoRank
+		moved      bool
+		wantErr    bool
+	}{
+		{"current position", b, &a, &c, false, false},
+		{"loose neighbors around item", b, &a, &d, false, false},
+		{"after itself", b, &b, &c, false, false},
+		{"before itself", b, &a, &b, false, false},
+		{"between itself and itself", b, &b, &b, false, false},
+		{"only item in list", b, nil, nil, false, false},
+		{"already first", a, nil, &b, false, false},
+		{"already last", d, &c, nil, false, false},
+		{"down", a, &c, &d, true, false},
+		{"up", d, &a, &b, true, false},
+		{"to front", c, nil, &a, true, false},
+		{"to end", a, &d, nil, true, false},
+		{"out of another bucket", other, &a, &c, true, false},
+		{"reversed neighbors", d, &c, &a, false, true},
+		{"equal neighbors", d, &a, &a, false, true},
+		{"itself with reversed neighbor", b, &b, &a, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, moved, err := gexorank.Move(tt.item, tt.prev, tt.next)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Move = %q, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Move error: %v", err)
+			}
+			if moved != tt.moved {
+				t.Errorf("moved = %v, want %v", moved, tt.moved)
+			}
+			if !moved && got != tt.item {
+				t.Errorf("no-op Move = %q, want item %q unchanged", got, tt.item)
+			}
+			if tt.prev != nil && got.CompareTo(*tt.prev) < 0 {
+				t.Errorf("Move = %q, sorts before prev %q", got, tt.prev)
+			}
+			if tt.next != nil && got.CompareTo(*tt.next) > 0 {
+				t.Errorf("Move = %q, sorts after next %q", got, tt.next)
+			}
+		})
+	}
+}
+
+func TestMove_MatchesGenBetween(t *testing.T) {
+	item, prev, next := mustParse(t, "0|a"), mustParse(t, "0|m"), mustParse(t, "0|n")
+	got, moved, err := gexorank.Move(item, &prev, &next)
+	if err != nil || !moved {
+		t.Fatalf("Move = %q, %v, %v; want a move", got, moved, err)
+	}
+	want, _ := gexorank.GenBetween(&prev, &next)
+	if got != want {
+		t.Errorf("Move = %q, want GenBetween result %q", got, want)
+	}
+}
+
+func TestRanker_Move(t *testing.T) {
+	rk, err := gexorank.NewRanker(gexorank.WithMaxLength(8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	prev, _ := rk.Parse("0|aaaaaaaa")
+	next, _ := rk.Parse("0|aaaaaaab")
+	item, _ := rk.Parse("0|z")
+	if _, _, err := rk.Move(item, &prev, &next); err == nil {
+		t.Error("Move into a full gap succeeded, want ErrRankExhausted")
+	}
+}
+
+func ExampleMove() {
+	a, _ := gexorank.Parse("0|a")
+	b, _ := gexorank.Parse("0|b")
+	c, _ := gexorank.Parse("0|c")
+
+	// Move c between a and b.
+	rank, moved, _ := gexorank.Move(c, &a, &b)
+	fmt.Println(rank, moved)
+
+	// b is already between a and c.
+	rank, moved, _ = gexorank.Move(b, &a, &c)
+	fmt.Println(rank, moved)
+	// Output:
+	// 0|ai true
+	// 0|b false
+}
+
+// --- MoveN Tests ---
+
+func TestMoveN(t *testing.T) {
+	// 30 selected cards, dropped between two adjacent ones.
+	items := make([]gexorank.LexoRank, 30)
+	for i := range items {
+		items[i] = mustParse(t, fmt.Sprintf("0|c%02d", i))
+	}
+	prev, next := mustParse(t, "0|m"), mustParse(t, "0|n")
+
+	got, err := gexorank.MoveN(items, &prev, &next)
+	if err != nil {
+		t.Fatalf("MoveN error: %v", err)
+	}
+	if len(got) != len(items) {
+		t.Fatalf("got %d ranks, want %d", len(got), len(items))
+	}
+	for i, r := range got {
+		if r.Len() > 2 {
+			t.Errorf("got[%d] = %q is %d chars, want at most 2", i, r, r.Len())
+		}
+		if r.CompareTo(prev) <= 0 || r.CompareTo(next) >= 0 {
+			t.Fatalf("got[%d] = %q not between %q and %q", i, r, prev, next)
+		}
+		if i > 0 && r.CompareTo(got[i-1]) <= 0 {
+			t.Fatalf("got[%d]=%q <= got[%d]=%q", i, r, i-1, got[i-1])
+		}
+	}
+
+	// The loop MoveN replaces grows the ranks by about one char per item.
+	lo := prev
+	for range items {
+		lo, _, _ = gexorank.Move(items[0], &lo, &next)
+	}
+	if lo.Len() <= got[len(got)-1].Len() {
+		t.Errorf("chained Move = %q, expected longer than MoveN's %q", lo, got[len(got)-1])
+	}
+}
+
+func TestMoveN_Ends(t *testing.T) {
+	a, b, c := mustParse(t, "1|a"), mustParse(t, "1|b"), mustParse(t, "1|c")
+
+	tests := []struct {
+		name       string
+		items      []gexorank.LexoRank
+		prev, next *gexorank.LexoRank
+		bucket     gexorank.Bucket
+	}{
+		{"to front", []gexorank.LexoRank{b, c}, nil, &a, gexorank.Bucket1},
+		{"to end", []gexorank.LexoRank{a, b}, &c, nil, gexorank.Bucket1},
+		{"whole list", []gexorank.LexoRank{a, b, c}, nil, nil, gexorank.Bucket1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := gexorank.MoveN(tt.items, tt.prev, tt.next)
+			if err != nil {
+				t.Fatalf("MoveN error: %v", err)
+			}
+			for i, r := range got {
+				if r.Bucket() != tt.bucket {
+					t.Errorf("got[%d] = %q, want bucket %v", i, r, tt.bucket)
+				}
+				if tt.prev != nil && r.CompareTo(*tt.prev) <= 0 || tt.next != nil && r.CompareTo(*tt.next) >= 0 {
+					t.Errorf("got[%d] = %q outside the target gap", i, r)
+				}
+				if i > 0 && r.CompareTo(got[i-1]) <= 0 {
+					t.Errorf("got[%d]=%q <= got[%d]=%q", i, r, i-1, got[i-1])
+				}
+			}
+		})
+	}
+}
+
+func TestMoveN_Errors(t *testing.T) {
+	a, b, c, d := mustParse(t, "0|a"), mustParse(t, "0|b"), mustParse(t, "0|c"), mustParse(t, "0|d")
+	other := mustParse(t, "1|a")
+
+	tests := []struct {
+		name       string
+		items      []gexorank.LexoRank
+		prev, next *gexorank.LexoRank
+	}{
+		{"unsorted items", []gexorank.LexoRank{b, a}, &c, &d},
+		{"duplicate items", []gexorank.LexoRank{a, a}, &c, &d},
+		{"neighbor is moving", []gexorank.LexoRank{a, c}, &c, &d},
+		{"reversed neighbors", []gexorank.LexoRank{a}, &d, &c},
+		{"across buckets", []gexorank.LexoRank{a}, &c, &other},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := gexorank.MoveN(tt.items, tt.prev, tt.next); err == nil {
+				t.Errorf("MoveN = %q, want error", got)
+			}
+		})
+	}
+	if got, err := gexorank.MoveN(nil, &a, &b); err != nil || got != nil {
+		t.Errorf("MoveN(nil) = %q, %v; want nil, nil", got, err)
+	}
+}
+
+func ExampleMoveN() {
+	first, _ := gexorank.Parse("0|a")
+	second, _ := gexorank.Parse("0|b")
+	selection := []gexorank.LexoRank{}
+	for _, s := range []string{"0|m", "0|p", "0|x"} {
+		r, _ := gexorank.Parse(s)
+		selection = append(selection, r)
+	}
+
+	ranks, _ := gexorank.MoveN(selection, &first, &second)
+	fmt.Println(ranks)
+	// Output: [0|a9 0|ai 0|ar]
+}
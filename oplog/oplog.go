@@ -0,0 +1,383 @@
+// Package oplog records rank changes as operations that can be serialized,
+// replayed, inverted for undo and compacted, so that clients and servers can
+// share one history of ordering changes.
+//
+// An [Op] is an [Insert], [Move], [Remove] or [Rebalance] and lists, for
+// every item it touches, the old and the new rank. Keeping both makes an op
+// self-inverting ([Op.Invert]) and lets a [Target] check that it applies to
+// the state it was recorded against, the way [gexorank.RankStore.CompareAndSet]
+// does: an undo that would overwrite someone else's later change fails with
+// [gexorank.ErrConflict] instead.
+//
+// Ops marshal to JSON with ranks as strings:
+//
+//	{"kind":"move","changes":[{"id":"task-1","old":"0|i","new":"0|k"}]}
+//
+// [encoding/json.Unmarshal] parses ranks back with [gexorank.Parse]; use
+// [Decode] for logs of ranks from a custom [gexorank.Ranker].
+package oplog
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/lupppig/gexorank"
+)
+
+// Kind identifies what an [Op] does.
+type Kind uint8
+
+const (
+	// KindInsert adds one item.
+	KindInsert Kind = iota
+	// KindMove changes the rank of one item.
+	KindMove
+	// KindRemove deletes one item.
+	KindRemove
+	// KindRebalance changes the ranks of many items at once.
+	KindRebalance
+)
+
+var kindNames = [...]string{
+	KindInsert:    "insert",
+	KindMove:      "move",
+	KindRemove:    "remove",
+	KindRebalance: "rebalance",
+}
+
+// String returns the kind name, e.g. "move".
+func (k Kind) String() string {
+	if int(k) < len(kindNames) {
+		return kindNames[k]
+	}
+	return fmt.Sprintf("Kind(%d)", uint8(k))
+}
+
+// MarshalText implements [encoding.TextMarshaler].
+func (k Kind) MarshalText() ([]byte, error) {
+	if int(k) >= len(kindNames) {
+		return nil, fmt.Errorf("oplog: unknown kind %d", uint8(k))
+	}
+	return []byte(kindNames[k]), nil
+}
+
+// UnmarshalText implements [encoding.TextUnmarshaler].
+func (k *Kind) UnmarshalText(text []byte) error {
+	i := slices.Index(kindNames[:], string(text))
+	if i < 0 {
+		return fmt.Errorf("oplog: unknown kind %q", text)
+	}
+	*k = Kind(i)
+	return nil
+}
+
+// Change is the rank change of one item. Old is the zero value when the item
+// is inserted and New is the zero value when it is removed.
+type Change struct {
+	ID  string            `json:"id"`
+	Old gexorank.LexoRank `json:"old"`
+	New gexorank.LexoRank `json:"new"`
+}
+
+// String formats the change as "id: old -> new", with "-" for a missing
+// rank.
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.ID, rankString(c.Old), rankString(c.New))
+}
+
+// Op is one recorded operation.
+type Op struct {
+	Kind    Kind     `json:"kind"`
+	Changes []Change `json:"changes"`
+}
+
+// Decode parses one JSON-encoded op, parsing its ranks with rk. A nil rk
+// means [gexorank.Default].
+func Decode(data []byte, rk *gexorank.Ranker) (Op, error) {
+	if rk == nil {
+		rk = gexorank.Default()
+	}
+	var wire struct {
+		Kind    Kind `json:"kind"`
+		Changes []struct {
+			ID  string  `json:"id"`
+			Old *string `json:"old"`
+			New *string `json:"new"`
+		} `json:"changes"`
+	}
+	if err := json.Unmarshal(data, &wire); err != nil {
+		return Op{}, fmt.Errorf("oplog: cannot decode op: %w", err)
+	}
+	parse := func(s *string) (gexorank.LexoRank, error) {
+		if s == nil {
+			return gexorank.LexoRank{}, nil
+		}
+		return rk.Parse(*s)
+	}
+	op := Op{Kind: wire.Kind, Changes: make([]Change, len(wire.Changes))}
+	for i, c := range wire.Changes {
+		old, err := parse(c.Old)
+		if err != nil {
+			return Op{}, fmt.Errorf("oplog: cannot decode change of %s: %w", c.ID, err)
+		}
+		rank, err := parse(c.New)
+		if err != nil {
+			return Op{}, fmt.Errorf("oplog: cannot decode change of %s: %w", c.ID, err)
+		}
+		op.Changes[i] = Change{ID: c.ID, Old: old, New: rank}
+	}
+	return op, nil
+}
+
+// Insert records adding item id at rank.
+func Insert(id string, rank gexorank.LexoRank) Op {
+	return Op{Kind: KindInsert, Changes: []Change{{ID: id, New: rank}}}
+}
+
+// Move records changing the rank of item id from old to rank.
+func Move(id string, old, rank gexorank.LexoRank) Op {
+	return Op{Kind: KindMove, Changes: []Change{{ID: id, Old: old, New: rank}}}
+}
+
+// Remove records deleting item id, whose rank was old.
+func Remove(id string, old gexorank.LexoRank) Op {
+	return Op{Kind: KindRemove, Changes: []Change{{ID: id, Old: old}}}
+}
+
+// Rebalance records changing many ranks at once.
+func Rebalance(changes ...Change) Op {
+	return Op{Kind: KindRebalance, Changes: slices.Clone(changes)}
+}
+
+// FromPlan records the changes returned by [gexorank.PlanRebalance],
+// [gexorank.Repair] and friends as a rebalance, where ids[i] is the ID of the
+// item at index i of the planned slice.
+func FromPlan(ids []string, plan []gexorank.RebalanceChange) Op {
+	changes := make([]Change, len(plan))
+	for i, c := range plan {
+		changes[i] = Change{ID: ids[c.Index], Old: c.Old, New: c.New}
+	}
+	return Op{Kind: KindRebalance, Changes: changes}
+}
+
+// Invert returns the op that undoes op: an insert becomes a remove and vice
+// versa, and moves and rebalances swap their old and new ranks.
+func (op Op) Invert() Op {
+	kind := op.Kind
+	switch kind {
+	case KindInsert:
+		kind = KindRemove
+	case KindRemove:
+		kind = KindInsert
+	}
+	changes := make([]Change, len(op.Changes))
+	for i, c := range op.Changes {
+		changes[len(changes)-1-i] = Change{ID: c.ID, Old: c.New, New: c.Old}
+	}
+	return Op{Kind: kind, Changes: changes}
+}
+
+// Validate reports whether op is well formed: single-item kinds have exactly
+// one change, inserts have only a new rank, removes only an old one, and
+// every other change has both and touches each item once.
+func (op Op) Validate() error {
+	if op.Kind >= Kind(len(kindNames)) {
+		return fmt.Errorf("oplog: unknown kind %s", op.Kind)
+	}
+	if op.Kind != KindRebalance && len(op.Changes) != 1 {
+		return fmt.Errorf("oplog: %s has %d changes, want 1", op.Kind, len(op.Changes))
+	}
+	seen := make(map[string]bool, len(op.Changes))
+	for _, c := range op.Changes {
+		hasOld, hasNew := !isZero(c.Old), !isZero(c.New)
+		switch {
+		case c.ID == "":
+			return fmt.Errorf("oplog: %s has a change without an item ID", op.Kind)
+		case seen[c.ID]:
+			return fmt.Errorf("oplog: %s changes %s twice", op.Kind, c.ID)
+		case op.Kind == KindInsert && (hasOld || !hasNew),
+			op.Kind == KindRemove && (!hasOld || hasNew),
+			(op.Kind == KindMove || op.Kind == KindRebalance) && (!hasOld || !hasNew):
+			return fmt.Errorf("oplog: invalid %s change %s", op.Kind, c)
+		}
+		seen[c.ID] = true
+	}
+	return nil
+}
+
+// String formats the op as "move [a: 0|i -> 0|k]".
+func (op Op) String() string {
+	parts := make([]string, len(op.Changes))
+	for i, c := range op.Changes {
+		parts[i] = c.String()
+	}
+	return fmt.Sprintf("%s [%s]", op.Kind, strings.Join(parts, ", "))
+}
+
+// Target is the state ops are replayed onto, such as a database table or a
+// [State]. Apply must check that every item's current rank equals the
+// change's old rank (absent for a zero old), returning
+// [gexorank.ErrConflict] or [gexorank.ErrNotFound] otherwise, and apply all
+// changes of the op or none.
+type Target interface {
+	Apply(op Op) error
+}
+
+// Replay applies ops to t in order and stops at the first failure.
+func Replay(t Target, ops ...Op) error {
+	for i, op := range ops {
+		if err := t.Apply(op); err != nil {
+			return fmt.Errorf("oplog: replay op %d (%s): %w", i, op, err)
+		}
+	}
+	return nil
+}
+
+// State is an in-memory [Target] mapping item IDs to ranks.
+type State map[string]gexorank.LexoRank
+
+// Apply applies op if it is valid and every item's current rank matches the
+// old rank recorded in op; otherwise it changes nothing.
+func (s State) Apply(op Op) error {
+	if err := op.Validate(); err != nil {
+		return err
+	}
+	for _, c := range op.Changes {
+		cur, ok := s[c.ID]
+		switch {
+		case isZero(c.Old) && ok:
+			return fmt.Errorf("%w: %s already exists at %s", gexorank.ErrConflict, c.ID, cur)
+		case !isZero(c.Old) && !ok:
+			return fmt.Errorf("%w: %s", gexorank.ErrNotFound, c.ID)
+		case !isZero(c.Old) && cur.String() != c.Old.String():
+			return fmt.Errorf("%w: %s has rank %s, not %s", gexorank.ErrConflict, c.ID, cur, c.Old)
+		}
+	}
+	for _, c := range op.Changes {
+		if isZero(c.New) {
+			delete(s, c.ID)
+		} else {
+			s[c.ID] = c.New
+		}
+	}
+	return nil
+}
+
+// Compact returns ops reduced to each item's net change, in the order the
+// items first appear: a chain of moves becomes one move, an insert followed
+// by a remove disappears, and a rebalance is split into moves. Replaying the
+// result onto a state gives the same final state as replaying ops, but the
+// intermediate states differ, so apply it as one batch (for example in a
+// single transaction) when the target enforces unique ranks.
+//
+// ops must be consistent: each change's old rank must be the previous new
+// rank of the item. Compact returns an error otherwise.
+func Compact(ops []Op) ([]Op, error) {
+	var order []string
+	net := make(map[string]*Change)
+	for i, op := range ops {
+		if err := op.Validate(); err != nil {
+			return nil, fmt.Errorf("oplog: compact op %d: %w", i, err)
+		}
+		for _, c := range op.Changes {
+			n, ok := net[c.ID]
+			if !ok {
+				order = append(order, c.ID)
+				net[c.ID] = &Change{ID: c.ID, Old: c.Old, New: c.New}
+				continue
+			}
+			if n.New.String() != c.Old.String() {
+				return nil, fmt.Errorf("oplog: compact op %d: %s follows %s", i, c, n)
+			}
+			n.New = c.New
+		}
+	}
+
+	var out []Op
+	for _, id := range order {
+		c := net[id]
+		switch {
+		case isZero(c.Old) && isZero(c.New), !isZero(c.Old) && c.Old.String() == c.New.String():
+			continue
+		case isZero(c.Old):
+			out = append(out, Insert(id, c.New))
+		case isZero(c.New):
+			out = append(out, Remove(id, c.Old))
+		default:
+			out = append(out, Move(id, c.Old, c.New))
+		}
+	}
+	return out, nil
+}
+
+// History is an undo/redo stack of applied ops.
+//
+// A History is not safe for concurrent use. The zero value is an empty
+// history.
+type History struct {
+	done, undone []Op
+}
+
+// Record adds op, which has just been applied, to the history and clears
+// the redo stack.
+func (h *History) Record(op Op) {
+	h.done = append(h.done, op)
+	h.undone = nil
+}
+
+// Undo returns the inverse of the last recorded or redone op, to be applied
+// by the caller, and moves the op to the redo stack. It returns false if
+// there is nothing to undo.
+func (h *History) Undo() (Op, bool) {
+	if len(h.done) == 0 {
+		return Op{}, false
+	}
+	op := h.done[len(h.done)-1]
+	h.done = h.done[:len(h.done)-1]
+	h.undone = append(h.undone, op)
+	return op.Invert(), true
+}
+
+// Redo returns the last undone op, to be applied again by the caller, and
+// moves it back to the history. It returns false if there is nothing to
+// redo.
+func (h *History) Redo() (Op, bool) {
+	if len(h.undone) == 0 {
+		return Op{}, false
+	}
+	op := h.undone[len(h.undone)-1]
+	h.undone = h.undone[:len(h.undone)-1]
+	h.done = append(h.done, op)
+	return op, true
+}
+
+// Ops returns the applied ops, oldest first; undone ops are not included.
+func (h *History) Ops() []Op {
+	return slices.Clone(h.done)
+}
+
+// Compact replaces the applied ops with their net change (see [Compact]),
+// so the history takes less space at the price of coarser undo steps. The
+// redo stack is cleared.
+func (h *History) Compact() error {
+	ops, err := Compact(h.done)
+	if err != nil {
+		return err
+	}
+	h.done, h.undone = ops, nil
+	return nil
+}
+
+// isZero reports whether r is the zero LexoRank, which marks a missing rank.
+func isZero(r gexorank.LexoRank) bool {
+	return r.RankString() == ""
+}
+
+func rankString(r gexorank.LexoRank) string {
+	if isZero(r) {
+		return "-"
+	}
+	return r.String()
+}
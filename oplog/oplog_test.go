@@ -0,0 +1,398 @@
+package oplog_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lupppig/gexorank"
+	"github.com/lupppig/gexorank/oplog"
+)
+
+func mustParse(t *testing.T, s string) gexorank.LexoRank {
+	t.Helper()
+	r, err := gexorank.Parse(s)
+	if err != nil {
+		t.Fatalf("Parse(%q) error: %v", s, err)
+	}
+	return r
+}
+
+// dump formats s as "id=rank" pairs sorted by ID.
+func dump(s oplog.State) string {
+	var out []string
+	for _, id := range slices.Sorted(maps.Keys(s)) {
+		out = append(out, id+"="+s[id].String())
+	}
+	return strings.Join(out, " ")
+}
+
+func TestKind_Text(t *testing.T) {
+	for _, k := range []oplog.Kind{oplog.KindInsert, oplog.KindMove, oplog.KindRemove, oplog.KindRebalance} {
+		text, err := k.MarshalText()
+		if err != nil {
+			t.Fatalf("MarshalText(%s) error: %v", k, err)
+		}
+		var got oplog.Kind
+		if err := got.UnmarshalText(text); err != nil || got != k {
+			t.Errorf("UnmarshalText(%s) = %s, %v", text, got, err)
+		}
+	}
+	var k oplog.Kind
+	if err := k.UnmarshalText([]byte("shuffle")); err == nil {
+		t.Error("UnmarshalText(shuffle) succeeded")
+	}
+	if _, err := oplog.Kind(9).MarshalText(); err == nil {
+		t.Error("MarshalText(9) succeeded")
+	}
+}
+
+func TestOp_JSON(t *testing.T) {
+	a, b := mustParse(t, "0|i"), mustParse(t, "0|k")
+	ops := []oplog.Op{
+		oplog.Insert("x", a),
+		oplog.Move("x", a, b),
+		oplog.Remove("x", b),
+		oplog.Rebalance(oplog.Change{ID: "x", Old: a, New: b}, oplog.Change{ID: "y", Old: b, New: a}),
+	}
+	data, err := json.Marshal(ops)
+	if err != nil {
+		t.Fatalf("Marshal error: %v", err)
+	}
+	want := `{"kind":"move","changes":[{"id":"x","old":"0|i","new":"0|k"}]}`
+	if !strings.Contains(string(data), want) || !strings.Contains(string(data), `"old":null`) {
+		t.Errorf("Marshal = %s, want it to contain %s and a null old rank", data, want)
+	}
+
+	var got []oplog.Op
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("Unmarshal error: %v", err)
+	}
+	if fmt.Sprint(got) != fmt.Sprint(ops) {
+		t.Errorf("round trip = %v, want %v", got, ops)
+	}
+	for _, op := range got {
+		if err := op.Validate(); err != nil {
+			t.Errorf("decoded %s is invalid: %v", op, err)
+		}
+	}
+}
+
+func TestDecode(t *testing.T) {
+	rk, err := gexorank.NewRanker(gexorank.WithAlphabet(gexorank.Base62), gexorank.WithDefaultLength(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, err := rk.Parse("0|VZ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := rk.GenBetween(&a, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, op := range []oplog.Op{oplog.Insert("x", a), oplog.Move("x", a, b), oplog.Remove("x", b)} {
+		data, err := json.Marshal(op)
+		if err != nil {
+			t.Fatalf("Marshal error: %v", err)
+		}
+		if err := json.Unmarshal(data, new(oplog.Op)); err == nil {
+			t.Errorf("json.Unmarshal(%s) should reject base62 ranks", data)
+		}
+		got, err := oplog.Decode(data, rk)
+		if err != nil {
+			t.Fatalf("Decode(%s) error: %v", data, err)
+		}
+		if got.String() != op.String() || got.Validate() != nil {
+			t.Errorf("Decode(%s) = %v, want %v", data, got, op)
+		}
+	}
+
+	for _, data := range []string{
+		`{"kind":"move","changes":[{"id":"x","old":"0|VZ","new":"0|!!"}]}`,
+		`{"kind":"teleport","changes":[]}`,
+		`[`,
+	} {
+		if _, err := oplog.Decode([]byte(data), rk); err == nil {
+			t.Errorf("Decode(%s) should return error", data)
+		}
+	}
+}
+
+func TestOp_Invert(t *testing.T) {
+	a, b := mustParse(t, "0|i"), mustParse(t, "0|k")
+	tests := []struct {
+		op   oplog.Op
+		want string
+	}{
+		{oplog.Insert("x", a), "remove [x: 0|i -> -]"},
+		{oplog.Remove("x", a), "insert [x: - -> 0|i]"},
+		{oplog.Move("x", a, b), "move [x: 0|k -> 0|i]"},
+		{
+			oplog.Rebalance(oplog.Change{ID: "x", Old: a, New: b}, oplog.Change{ID: "y", Old: b, New: a}),
+			"rebalance [y: 0|i -> 0|k, x: 0|k -> 0|i]",
+		},
+	}
+	for _, tt := range tests {
+		inv := tt.op.Invert()
+		if inv.String() != tt.want {
+			t.Errorf("Invert(%s) = %s, want %s", tt.op, inv, tt.want)
+		}
+		if err := inv.Validate(); err != nil {
+			t.Errorf("Invert(%s) is invalid: %v", tt.op, err)
+		}
+		if inv.Invert().String() != tt.op.String() {
+			t.Errorf("double Invert(%s) = %s", tt.op, inv.Invert())
+		}
+	}
+}
+
+func TestOp_Validate(t *testing.T) {
+	a, b := mustParse(t, "0|i"), mustParse(t, "0|k")
+	bad := []oplog.Op{
+		{Kind: 9, Changes: []oplog.Change{{ID: "x", New: a}}},
+		{Kind: oplog.KindInsert},
+		{Kind: oplog.KindInsert, Changes: []oplog.Change{{ID: "x", Old: a, New: b}}},
+		{Kind: oplog.KindRemove, Changes: []oplog.Change{{ID: "x", New: a}}},
+		{Kind: oplog.KindMove, Changes: []oplog.Change{{ID: "x", Old: a}}},
+		{Kind: oplog.KindMove, Changes: []oplog.Change{{Old: a, New: b}}},
+		oplog.Rebalance(oplog.Change{ID: "x", Old: a, New: b}, oplog.Change{ID: "x", Old: b, New: a}),
+	}
+	for _, op := range bad {
+		if err := op.Validate(); err == nil {
+			t.Errorf("Validate(%s) succeeded, want error", op)
+		}
+	}
+}
+
+func TestState_Apply(t *testing.T) {
+	a, b, c := mustParse(t, "0|a"), mustParse(t, "0|b"), mustParse(t, "0|c")
+	s := oplog.State{}
+	if err := oplog.Replay(s, oplog.Insert("x", a), oplog.Insert("y", b)); err != nil {
+		t.Fatalf("Replay error: %v", err)
+	}
+
+	tests := []struct {
+		op   oplog.Op
+		want error
+	}{
+		{oplog.Insert("x", c), gexorank.ErrConflict},
+		{oplog.Move("x", b, c), gexorank.ErrConflict},
+		{oplog.Move("z", a, c), gexorank.ErrNotFound},
+		{oplog.Remove("z", a), gexorank.ErrNotFound},
+		{oplog.Rebalance(oplog.Change{ID: "x", Old: a, New: c}, oplog.Change{ID: "y", Old: c, New: a}), gexorank.ErrConflict},
+	}
+	for _, tt := range tests {
+		if err := s.Apply(tt.op); !errors.Is(err, tt.want) {
+			t.Errorf("Apply(%s) error = %v, want %v", tt.op, err, tt.want)
+		}
+	}
+	// A failed rebalance changes nothing.
+	if got := dump(s); got != "x=0|a y=0|b" {
+		t.Errorf("state = %s, want x=0|a y=0|b", got)
+	}
+
+	err := oplog.Replay(s, oplog.Move("x", a, c), oplog.Remove("y", a))
+	if !errors.Is(err, gexorank.ErrConflict) || !strings.Contains(err.Error(), "op 1") {
+		t.Errorf("Replay error = %v, want a conflict at op 1", err)
+	}
+}
+
+func TestHistory_UndoRedo(t *testing.T) {
+	a, b, c := mustParse(t, "0|a"), mustParse(t, "0|b"), mustParse(t, "0|c")
+	s := oplog.State{}
+	var h oplog.History
+	do := func(op oplog.Op) {
+		t.Helper()
+		if err := s.Apply(op); err != nil {
+			t.Fatalf("Apply(%s) error: %v", op, err)
+		}
+		h.Record(op)
+	}
+	undo := func() {
+		t.Helper()
+		op, ok := h.Undo()
+		if !ok {
+			t.Fatal("nothing to undo")
+		}
+		if err := s.Apply(op); err != nil {
+			t.Fatalf("undo %s error: %v", op, err)
+		}
+	}
+	redo := func() {
+		t.Helper()
+		op, ok := h.Redo()
+		if !ok {
+			t.Fatal("nothing to redo")
+		}
+		if err := s.Apply(op); err != nil {
+			t.Fatalf("redo %s error: %v", op, err)
+		}
+	}
+
+	do(oplog.Insert("x", a))
+	do(oplog.Insert("y", b))
+	do(oplog.Move("x", a, c))
+	undo()
+	if got := dump(s); got != "x=0|a y=0|b" {
+		t.Fatalf("after undo: %s", got)
+	}
+	redo()
+	if got := dump(s); got != "x=0|c y=0|b" {
+		t.Fatalf("after redo: %s", got)
+	}
+	undo()
+	undo()
+	do(oplog.Remove("x", a)) // clears the redo stack
+	if _, ok := h.Redo(); ok {
+		t.Error("Redo after a new op succeeded")
+	}
+	undo()
+	undo()
+	if _, ok := h.Undo(); ok || len(s) != 0 {
+		t.Errorf("after undoing everything: state = %s, Undo ok = %v", dump(s), ok)
+	}
+
+	// Another writer moved x after it was recorded: the undo conflicts
+	// instead of clobbering the change.
+	do(oplog.Insert("x", a))
+	s["x"] = b
+	op, _ := h.Undo()
+	if err := s.Apply(op); !errors.Is(err, gexorank.ErrConflict) {
+		t.Errorf("stale undo error = %v, want ErrConflict", err)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	a, b, c := mustParse(t, "0|a"), mustParse(t, "0|b"), mustParse(t, "0|c")
+	ops := []oplog.Op{
+		oplog.Insert("x", a),
+		oplog.Insert("y", b),
+		oplog.Move("x", a, c),
+		oplog.Remove("y", b),
+		oplog.Insert("z", b),
+		oplog.Rebalance(oplog.Change{ID: "x", Old: c, New: a}, oplog.Change{ID: "z", Old: b, New: c}),
+	}
+	got, err := oplog.Compact(ops)
+	if err != nil {
+		t.Fatalf("Compact error: %v", err)
+	}
+	want := "[insert [x: - -> 0|a] insert [z: - -> 0|c]]"
+	if fmt.Sprint(got) != want {
+		t.Errorf("Compact = %v, want %s", got, want)
+	}
+
+	if _, err := oplog.Compact([]oplog.Op{oplog.Insert("x", a), oplog.Move("x", b, c)}); err == nil {
+		t.Error("Compact of inconsistent ops succeeded")
+	}
+
+	var h oplog.History
+	for _, op := range ops {
+		h.Record(op)
+	}
+	if err := h.Compact(); err != nil || fmt.Sprint(h.Ops()) != want {
+		t.Errorf("History.Compact = %v, %v; want %s", h.Ops(), err, want)
+	}
+}
+
+func TestCompact_Random(t *testing.T) {
+	// Replaying the compacted log from the same start gives the same state.
+	for seed := range uint64(20) {
+		rng := rand.New(rand.NewPCG(seed, 9))
+		start := oplog.State{}
+		for i := range 5 {
+			start[fmt.Sprint("s", i)] = mustParse(t, fmt.Sprintf("0|%c", 'a'+i))
+		}
+		s := maps.Clone(start)
+		var ops []oplog.Op
+		for n := range 100 {
+			ids := slices.Sorted(maps.Keys(s))
+			fresh := mustParse(t, fmt.Sprintf("0|m%d", n))
+			var op oplog.Op
+			switch k := rng.IntN(4); {
+			case k == 0 || len(ids) == 0:
+				op = oplog.Insert(fmt.Sprint("n", n), fresh)
+			case k == 1:
+				id := ids[rng.IntN(len(ids))]
+				op = oplog.Remove(id, s[id])
+			case k == 2:
+				id := ids[rng.IntN(len(ids))]
+				op = oplog.Move(id, s[id], fresh)
+			default:
+				var changes []oplog.Change
+				for _, id := range ids {
+					if rng.IntN(2) == 0 {
+						changes = append(changes, oplog.Change{ID: id, Old: s[id], New: mustParse(t, fmt.Sprintf("0|r%d%s", n, id))})
+					}
+				}
+				op = oplog.Rebalance(changes...)
+			}
+			if err := s.Apply(op); err != nil {
+				t.Fatalf("seed %d: Apply(%s) error: %v", seed, op, err)
+			}
+			ops = append(ops, op)
+		}
+
+		compacted, err := oplog.Compact(ops)
+		if err != nil {
+			t.Fatalf("seed %d: Compact error: %v", seed, err)
+		}
+		replayed := maps.Clone(start)
+		if err := oplog.Replay(replayed, compacted...); err != nil {
+			t.Fatalf("seed %d: Replay(compacted) error: %v", seed, err)
+		}
+		if dump(replayed) != dump(s) {
+			t.Fatalf("seed %d: compacted replay = %s, want %s", seed, dump(replayed), dump(s))
+		}
+		seen := map[string]bool{}
+		for _, op := range compacted {
+			if id := op.Changes[0].ID; seen[id] {
+				t.Fatalf("seed %d: %s appears twice in the compacted log", seed, id)
+			} else {
+				seen[id] = true
+			}
+		}
+
+		// Undoing the whole log restores the start.
+		undone := maps.Clone(s)
+		for i := len(ops) - 1; i >= 0; i-- {
+			if err := undone.Apply(ops[i].Invert()); err != nil {
+				t.Fatalf("seed %d: undo op %d error: %v", seed, i, err)
+			}
+		}
+		if dump(undone) != dump(start) {
+			t.Fatalf("seed %d: undone state = %s, want %s", seed, dump(undone), dump(start))
+		}
+	}
+}
+
+func ExampleHistory() {
+	first, _ := gexorank.Parse("0|i")
+	second, _ := gexorank.Parse("0|k")
+
+	state := oplog.State{}
+	var history oplog.History
+	for _, op := range []oplog.Op{
+		oplog.Insert("task-1", first),
+		oplog.Move("task-1", first, second),
+	} {
+		state.Apply(op)
+		history.Record(op)
+	}
+
+	undo, _ := history.Undo()
+	state.Apply(undo)
+	fmt.Println(undo, state["task-1"])
+
+	data, _ := json.Marshal(history.Ops())
+	fmt.Println(string(data))
+	// Output:
+	// move [task-1: 0|k -> 0|i] 0|i
+	// [{"kind":"insert","changes":[{"id":"task-1","old":null,"new":"0|i"}]}]
+}
@@ -17,48 +17,54 @@ const (
 	MaxLength = 128
 )
 
-// RankValue is an immutable, fixed-width, zero-padded base36 string
-// that represents a position in the ranking space.
+// RankValue is an immutable, fixed-width, zero-padded string in an
+// [Alphabet] (base36 by default) that represents a position in the ranking
+// space.
 //
-// All RankValue instances have a canonical form: lowercase base36 characters,
-// zero-padded to their length. This guarantees that standard string comparison
-// produces the correct sort order.
+// All RankValue instances have a canonical form: characters of their alphabet,
+// padded with the alphabet's minimum character to their length. This
+// guarantees that standard string comparison produces the correct sort order.
 type RankValue struct {
 	value string
+	alpha *Alphabet
 }
 
 // newRankValue creates a RankValue from a validated, canonical string.
 // The caller must ensure s is already valid and zero-padded.
-func newRankValue(s string) RankValue {
-	return RankValue{value: s}
+func newRankValue(s string, alpha *Alphabet) RankValue {
+	return RankValue{value: s, alpha: alpha}
 }
 
 // ParseRankValue validates and creates a RankValue from a raw string.
 // The string must consist entirely of base36 characters (0-9, a-z)
-// and must not be empty.
+// and must not be empty. Use [Alphabet.ParseRankValue] for other alphabets.
 func ParseRankValue(s string) (RankValue, error) {
-	if len(s) == 0 {
-		return RankValue{}, fmt.Errorf("gexorank: rank value must not be empty")
-	}
-	if err := alphabet.Validate(s); err != nil {
-		return RankValue{}, fmt.Errorf("gexorank: invalid rank value: %w", err)
-	}
-	return RankValue{value: s}, nil
+	return Base36.ParseRankValue(s)
 }
 
 // MinValue returns the minimum rank value of the given length (all '0's).
 func MinValue(length int) RankValue {
-	return RankValue{value: strings.Repeat(string(alphabet.Min()), length)}
+	return Base36.MinValue(length)
 }
 
 // MaxValue returns the maximum rank value of the given length (all 'z's).
 func MaxValue(length int) RankValue {
-	return RankValue{value: strings.Repeat(string(alphabet.Max()), length)}
+	return Base36.MaxValue(length)
 }
 
 // MidValue returns the midpoint rank value of the given length.
 func MidValue(length int) RankValue {
-	return RankValue{value: strings.Repeat(string(alphabet.Mid()), length)}
+	return Base36.MidValue(length)
+}
+
+// Alphabet returns the alphabet the value is encoded in.
+func (r RankValue) Alphabet() *Alphabet {
+	return r.alpha.orBase36()
+}
+
+// set returns the character set backing the value's alphabet.
+func (r RankValue) set() *alphabet.Set {
+	return r.Alphabet().set
 }
 
 // String returns the raw rank value string.
@@ -71,121 +77,109 @@ func (r RankValue) Len() int {
 	return len(r.value)
 }
 
-// CompareTo compares two rank values lexicographically.
+// CompareTo compares two rank values lexicographically, treating the shorter
+// value as padded with the alphabet's minimum character.
 // It returns -1, 0, or 1.
 func (r RankValue) CompareTo(other RankValue) int {
-	a, b := r.normalize(other)
-	if a < b {
-		return -1
-	}
-	if a > b {
-		return 1
-	}
-	return 0
-}
-
-// normalize ensures both values have the same length by zero-padding the shorter one.
-func (r RankValue) normalize(other RankValue) (string, string) {
-	a, b := r.value, other.value
-	for len(a) < len(b) {
-		a += string(alphabet.Min())
-	}
-	for len(b) < len(a) {
-		b += string(alphabet.Min())
-	}
-	return a, b
+	return comparePadded(r.set(), r.value, other.value)
 }
 
 // Between returns a new RankValue that lies between r and other.
-// If r equals other, an error is returned.
+// If r equals other, or the values use different alphabets, an error is returned.
 // If no midpoint exists at the current precision, the values are extended
 // by one character. If extension would exceed MaxLength, ErrRankExhausted is returned.
 func (r RankValue) Between(other RankValue) (RankValue, error) {
+	return r.between(other, MaxLength)
+}
+
+// between implements [RankValue.Between] with the given length limit.
+func (r RankValue) between(other RankValue, maxLength int) (RankValue, error) {
+	mid, err := r.midpoint(other, maxLength)
+	if err != nil {
+		return RankValue{}, err
+	}
+
+	return newRankValue(canonicalStr(r.set(), mid), r.alpha), nil
+}
+
+// midpoint returns the untrimmed midpoint of r and other. The result is
+// strictly greater than the lower value and strictly less than the upper value
+// at its own width, so any suffix can be appended without breaking the order.
+func (r RankValue) midpoint(other RankValue, maxLength int) (string, error) {
+	if !r.Alphabet().equal(other.Alphabet()) {
+		return "", fmt.Errorf("gexorank: cannot compute midpoint of rank values in different alphabets")
+	}
 	if r.CompareTo(other) == 0 {
-		return RankValue{}, fmt.Errorf("gexorank: cannot compute midpoint of equal rank values")
+		return "", fmt.Errorf("gexorank: cannot compute midpoint of equal rank values")
 	}
 
+	set := r.set()
+
 	// Ensure lower < upper.
 	lower, upper := r, other
 	if r.CompareTo(other) > 0 {
 		lower, upper = other, r
 	}
 
-	lo, hi := lower.normalize(upper)
+	// Neighbors may be longer than maxLength (e.g. when they carry a replica
+	// tag); their midpoint is then taken from their truncations, which still
+	// sorts strictly between them whenever it lands above lower.
+	width := min(max(lower.Len(), upper.Len()), maxLength)
+	mid := midpointStr(set, lower.value, upper.value, width)
 
-	mid, err := midpointStr(lo, hi)
-	if err != nil {
-		return RankValue{}, err
-	}
-
-	// If midpoint equals lower, we need more precision.
-	if mid == lo {
-		if len(lo)+1 > MaxLength {
-			return RankValue{}, ErrRankExhausted
+	// If midpoint is not above lower, we need more precision.
+	if comparePadded(set, mid, lower.value) <= 0 {
+		if width+1 > maxLength {
+			return "", ErrRankExhausted
 		}
 		// Extend both by one character and retry.
-		lo += string(alphabet.Min())
-		hi += string(alphabet.Min())
-		mid, err = midpointStr(lo, hi)
-		if err != nil {
-			return RankValue{}, err
-		}
+		width++
+		mid = midpointStr(set, lower.value, upper.value, width)
 	}
 
-	// Trim trailing '0's, but never below the original length of the shorter value.
-	minLen := min(lower.Len(), upper.Len())
-	mid = trimTrailingZeros(mid, minLen)
-
-	return RankValue{value: mid}, nil
+	return mid, nil
 }
 
-// Increment returns a new RankValue one step above r.
+// Increment returns a new RankValue one step above r at the same length.
+// The maximum value of a length is returned unchanged.
 func (r RankValue) Increment() RankValue {
-	n := strToBigInt(r.value)
-	n.Add(n, big.NewInt(1))
-	result := bigIntToStr(n, len(r.value))
-	return RankValue{value: result}
+	return newRankValue(incrementStr(r.set(), r.value), r.alpha)
 }
 
-// Decrement returns a new RankValue one step below r.
+// Decrement returns a new RankValue one step below r at the same length.
+// The minimum value of a length is returned unchanged.
 func (r RankValue) Decrement() RankValue {
-	n := strToBigInt(r.value)
-	n.Sub(n, big.NewInt(1))
-	if n.Sign() < 0 {
-		n.SetInt64(0)
-	}
-	result := bigIntToStr(n, len(r.value))
-	return RankValue{value: result}
+	return newRankValue(decrementStr(r.set(), r.value), r.alpha)
 }
 
 // --- big.Int helpers ---
 
-// strToBigInt converts a base36 string to a *big.Int.
-func strToBigInt(s string) *big.Int {
-	base := big.NewInt(int64(alphabet.Size))
+// strToBigInt converts a string in the given alphabet to a *big.Int.
+func strToBigInt(set *alphabet.Set, s string) *big.Int {
+	base := big.NewInt(int64(set.Size()))
 	result := new(big.Int)
 	for i := 0; i < len(s); i++ {
-		v := alphabet.ToVal(s[i])
+		v := set.ToVal(s[i])
 		result.Mul(result, base)
 		result.Add(result, big.NewInt(int64(v)))
 	}
 	return result
 }
 
-// bigIntToStr converts a *big.Int back to a base36 string of at least minLen.
-func bigIntToStr(n *big.Int, minLen int) string {
+// bigIntToStr converts a *big.Int back to a string in the given alphabet of at least minLen.
+func bigIntToStr(set *alphabet.Set, n *big.Int, minLen int) string {
 	if n.Sign() == 0 {
-		return strings.Repeat(string(alphabet.Min()), minLen)
+		return strings.Repeat(string(set.Min()), minLen)
 	}
 
-	base := big.NewInt(int64(alphabet.Size))
+	base := big.NewInt(int64(set.Size()))
 	mod := new(big.Int)
 	work := new(big.Int).Set(n)
 
 	var buf []byte
 	for work.Sign() > 0 {
 		work.DivMod(work, base, mod)
-		buf = append(buf, alphabet.ToChar(int(mod.Int64())))
+		buf = append(buf, set.ToChar(int(mod.Int64())))
 	}
 
 	// Reverse.
@@ -195,28 +189,92 @@ func bigIntToStr(n *big.Int, minLen int) string {
 
 	// Pad to minLen.
 	for len(buf) < minLen {
-		buf = append([]byte{alphabet.Min()}, buf...)
+		buf = append([]byte{set.Min()}, buf...)
 	}
 
 	return string(buf)
 }
 
-// midpointStr calculates the midpoint between two equal-length base36 strings.
-func midpointStr(lo, hi string) (string, error) {
-	a := strToBigInt(lo)
-	b := strToBigInt(hi)
+// spreadStr returns n values strictly between lo and hi, evenly spaced at the
+// shortest width (up to maxLength) that fits them. An empty lo or hi stands for
+// the open bottom or top of the ranking space. The values are returned in
+// ascending order with trailing minimum characters trimmed.
+//
+// A non-empty suffix is appended to every value instead of trimming; the
+// values then stay strictly below hi's truncation so the suffix cannot push
+// them past it.
+func spreadStr(set *alphabet.Set, lo, hi string, n, maxLength int, suffix string) ([]string, error) {
+	base := big.NewInt(int64(set.Size()))
+	count := big.NewInt(int64(n))
+	divisor := big.NewInt(int64(n + 1))
+	limit := big.NewInt(1) // base^width
+
+	for width := 1; width <= maxLength; width++ {
+		limit.Mul(limit, base)
+
+		// Values in the open interval (a, b) at this width all sort strictly
+		// between lo and hi.
+		a := new(big.Int)
+		if lo != "" {
+			a = floorAt(set, lo, width)
+		}
+		b := new(big.Int).Set(limit)
+		switch {
+		case hi != "" && suffix != "":
+			b = floorAt(set, hi, width)
+		case hi != "":
+			b = ceilAt(set, hi, width)
+		}
 
-	// mid = (a + b) / 2
-	sum := new(big.Int).Add(a, b)
-	mid := new(big.Int).Div(sum, big.NewInt(2))
+		gap := new(big.Int).Sub(b, a)
+		if gap.Cmp(count) <= 0 {
+			continue
+		}
 
-	return bigIntToStr(mid, len(lo)), nil
+		result := make([]string, n)
+		val := new(big.Int)
+		for i := range n {
+			// val = a + gap * (i + 1) / (n + 1)
+			val.Mul(gap, big.NewInt(int64(i+1)))
+			val.Div(val, divisor)
+			val.Add(val, a)
+			if suffix != "" {
+				result[i] = bigIntToStr(set, val, width) + suffix
+			} else {
+				result[i] = canonicalStr(set, bigIntToStr(set, val, width))
+			}
+		}
+		return result, nil
+	}
+
+	return nil, ErrRankExhausted
+}
+
+// floorAt returns the numeric value of s truncated or zero-padded to width.
+func floorAt(set *alphabet.Set, s string, width int) *big.Int {
+	if len(s) <= width {
+		n := strToBigInt(set, s)
+		return n.Mul(n, new(big.Int).Exp(big.NewInt(int64(set.Size())), big.NewInt(int64(width-len(s))), nil))
+	}
+	return strToBigInt(set, s[:width])
+}
+
+// ceilAt returns the smallest value at width that is greater than or equal to s.
+func ceilAt(set *alphabet.Set, s string, width int) *big.Int {
+	n := floorAt(set, s, width)
+	for i := width; i < len(s); i++ {
+		if s[i] != set.Min() {
+			return n.Add(n, big.NewInt(1))
+		}
+	}
+	return n
 }
 
-// trimTrailingZeros removes trailing '0' characters but keeps at least minLen.
-func trimTrailingZeros(s string, minLen int) string {
+// canonicalStr removes trailing minimum characters, keeping at least one
+// character so that the minimum value stays non-empty.
+func canonicalStr(set *alphabet.Set, s string) string {
 	end := len(s)
-	for end > minLen && s[end-1] == alphabet.Min() {
+	for end > 1 && s[end-1] == set.Min() {
 		end--
 	}
 	return s[:end]
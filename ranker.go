@@ -0,0 +1,459 @@
+package gexorank
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+	"sync"
+)
+
+// Ranker generates and parses ranks with its own alphabet, length limits and
+// bucket count. Ranks produced by a Ranker remember it, so methods such as
+// [LexoRank.GenNext] and [LexoRank.NeedsRebalance] enforce the same limits.
+//
+// The package-level functions ([Initial], [Parse], [Between], ...) use
+// [Default], which is configured with [Base36], [DefaultLength], [MaxLength]
+// and three buckets.
+//
+// A Ranker is immutable and safe for concurrent use. [NewRanker] returns the
+// same pointer for the same settings (except with [WithJitter]), so ranks
+// from equally configured Rankers compare equal with == and work as map keys.
+type Ranker struct {
+	alpha         *Alphabet
+	defaultLength int
+	maxLength     int
+	buckets       int
+	step          uint64
+	rng           *lockedRand
+	replica       string
+	suffix        string
+}
+
+// Option configures a [Ranker].
+type Option func(*Ranker)
+
+// WithAlphabet sets the alphabet rank values are encoded in.
+func WithAlphabet(a *Alphabet) Option {
+	return func(rk *Ranker) {
+		rk.alpha = a
+	}
+}
+
+// WithDefaultLength sets the width of freshly generated and rebalanced values.
+func WithDefaultLength(n int) Option {
+	return func(rk *Ranker) {
+		rk.defaultLength = n
+	}
+}
+
+// WithMaxLength sets the maximum rank value length. Operations that would
+// exceed it return [ErrRankExhausted].
+func WithMaxLength(n int) Option {
+	return func(rk *Ranker) {
+		rk.maxLength = n
+	}
+}
+
+// WithBucketCount sets the number of buckets in the rotation (2 to 10).
+func WithBucketCount(n int) Option {
+	return func(rk *Ranker) {
+		rk.buckets = n
+	}
+}
+
+// WithStep sets how far [LexoRank.GenNext] and [LexoRank.GenPrev] move a rank,
+// in units of the last digit at the default length. Zero selects the default
+// of Size^(DefaultLength-3), which leaves three digits of room between
+// consecutive appended ranks (46656 positions for base36).
+func WithStep(step uint64) Option {
+	return func(rk *Ranker) {
+		rk.step = step
+	}
+}
+
+// defaultRanker backs the package-level functions.
+var defaultRanker = internRanker(&Ranker{
+	alpha:         Base36,
+	defaultLength: DefaultLength,
+	maxLength:     MaxLength,
+	buckets:       bucketCount,
+	step:          defaultStep(Base36, DefaultLength),
+})
+
+// rankers maps the settings of every Ranker created so far without a random
+// source to it.
+var rankers sync.Map
+
+// internRanker returns the Ranker already created with rk's settings, or
+// registers and returns rk. Rankers with a random source are never shared.
+func internRanker(rk *Ranker) *Ranker {
+	if rk.rng != nil {
+		return rk
+	}
+	v, _ := rankers.LoadOrStore(*rk, rk)
+	return v.(*Ranker)
+}
+
+// defaultStep returns size^(length-3), capped so that it fits in a uint64.
+func defaultStep(a *Alphabet, length int) uint64 {
+	base := uint64(a.Size())
+	step := uint64(1)
+	for i := 3; i < length && step <= math.MaxUint64/base; i++ {
+		step *= base
+	}
+	return step
+}
+
+// Default returns the Ranker used by the package-level functions.
+func Default() *Ranker {
+	return defaultRanker
+}
+
+// NewRanker creates a Ranker. Options not supplied fall back to the settings
+// of [Default]. It returns an error if the resulting configuration is invalid.
+func NewRanker(opts ...Option) (*Ranker, error) {
+	rk := *defaultRanker
+	rk.step = 0
+	for _, opt := range opts {
+		opt(&rk)
+	}
+
+	if rk.alpha == nil {
+		return nil, fmt.Errorf("gexorank: alphabet must not be nil")
+	}
+	if rk.defaultLength < 1 {
+		return nil, fmt.Errorf("gexorank: default length must be positive, got %d", rk.defaultLength)
+	}
+	if rk.maxLength < rk.defaultLength {
+		return nil, fmt.Errorf("gexorank: max length %d is shorter than default length %d", rk.maxLength, rk.defaultLength)
+	}
+	if rk.buckets < 2 || rk.buckets > 10 {
+		return nil, fmt.Errorf("gexorank: bucket count must be between 2 and 10, got %d", rk.buckets)
+	}
+	if rk.step == 0 {
+		rk.step = defaultStep(rk.alpha, rk.defaultLength)
+	}
+	if err := rk.initReplica(); err != nil {
+		return nil, err
+	}
+	return internRanker(&rk), nil
+}
+
+// Alphabet returns the alphabet rank values are encoded in.
+func (rk *Ranker) Alphabet() *Alphabet {
+	return rk.alpha
+}
+
+// DefaultLength returns the width of freshly generated and rebalanced values.
+func (rk *Ranker) DefaultLength() int {
+	return rk.defaultLength
+}
+
+// MaxLength returns the maximum rank value length.
+func (rk *Ranker) MaxLength() int {
+	return rk.maxLength
+}
+
+// Step returns the distance [LexoRank.GenNext] and [LexoRank.GenPrev] move a
+// rank at the default length.
+func (rk *Ranker) Step() uint64 {
+	return rk.step
+}
+
+// BucketCount returns the number of buckets in the rotation.
+func (rk *Ranker) BucketCount() int {
+	return rk.buckets
+}
+
+// NextBucket returns the bucket after b in the rotation (e.g. 0→1→2→0).
+func (rk *Ranker) NextBucket(b Bucket) Bucket {
+	return Bucket((int(b) + 1) % rk.buckets)
+}
+
+// PrevBucket returns the bucket before b in the rotation (e.g. 0→2→1→0).
+func (rk *Ranker) PrevBucket(b Bucket) Bucket {
+	return Bucket((int(b) + rk.buckets - 1) % rk.buckets)
+}
+
+// ParseBucket parses a single-digit bucket that is valid for this Ranker.
+func (rk *Ranker) ParseBucket(s string) (Bucket, error) {
+	if len(s) != 1 || s[0] < '0' || int(s[0]-'0') >= rk.buckets {
+		return 0, fmt.Errorf("gexorank: invalid bucket %q, must be 0 to %d", s, rk.buckets-1)
+	}
+	return Bucket(s[0] - '0'), nil
+}
+
+// Parse parses a rank string in the format "{bucket}|{value}". The bucket must
+// be valid for this Ranker and the value must be encoded in its alphabet and
+// no longer than its max length. See the package-level [Parse] for opts.
+func (rk *Ranker) Parse(s string, opts ...ParseOption) (LexoRank, error) {
+	parts := strings.SplitN(s, separator, 2)
+	if len(parts) != 2 {
+		return LexoRank{}, fmt.Errorf("gexorank: invalid rank format %q, expected \"{bucket}|{value}\"", s)
+	}
+
+	bucket, err := rk.ParseBucket(parts[0])
+	if err != nil {
+		return LexoRank{}, err
+	}
+
+	value, err := rk.alpha.ParseRankValue(parts[1])
+	if err != nil {
+		return LexoRank{}, err
+	}
+	if value.Len() > rk.maxLength {
+		return LexoRank{}, fmt.Errorf("gexorank: rank value length %d exceeds max length %d", value.Len(), rk.maxLength)
+	}
+
+	return applyParseOptions(rk.rank(bucket, value), opts)
+}
+
+// Scanner returns a [database/sql.Scanner] that parses a column into r with
+// this Ranker, for use with [database/sql.Rows.Scan]:
+//
+//	var rank gexorank.LexoRank
+//	err := rows.Scan(&id, rk.Scanner(&rank))
+//
+// Scanning into r directly parses with the Ranker of the rank r already
+// holds, which is [Default] for a zero LexoRank.
+func (rk *Ranker) Scanner(r *LexoRank) sql.Scanner {
+	return rankScanner{rk: rk, dst: r}
+}
+
+// rankScanner is the [sql.Scanner] returned by [Ranker.Scanner].
+type rankScanner struct {
+	rk  *Ranker
+	dst *LexoRank
+}
+
+func (s rankScanner) Scan(src any) error {
+	bound := LexoRank{ranker: s.rk}
+	if err := bound.Scan(src); err != nil {
+		return err
+	}
+	*s.dst = bound
+	return nil
+}
+
+// Initial returns the starting rank in bucket 0 at the midpoint of the
+// ranking space, tagged with the replica ID if one is configured.
+func (rk *Ranker) Initial() LexoRank {
+	return rk.finish(Bucket0, rk.alpha.MidValue(rk.defaultLength).value)
+}
+
+// Min returns the minimum possible rank in bucket 0, in canonical form
+// ("0|0").
+func (rk *Ranker) Min() LexoRank {
+	return rk.rank(Bucket0, rk.alpha.MinValue(1))
+}
+
+// Max returns the maximum possible rank in bucket 0.
+func (rk *Ranker) Max() LexoRank {
+	return rk.rank(Bucket0, rk.alpha.MaxValue(rk.defaultLength))
+}
+
+// Between returns a new LexoRank that sorts between a and b.
+// Both ranks must be in the same bucket. If no midpoint can be computed
+// without exceeding the max length, [ErrRankExhausted] is returned.
+func (rk *Ranker) Between(a, b LexoRank) (LexoRank, error) {
+	if a.bucket != b.bucket {
+		return LexoRank{}, fmt.Errorf("gexorank: cannot compute midpoint across buckets %s and %s", a.bucket, b.bucket)
+	}
+	if !a.value.Alphabet().equal(rk.alpha) {
+		return LexoRank{}, fmt.Errorf("gexorank: rank %s is not encoded in the ranker's alphabet", a)
+	}
+
+	var mid string
+	var err error
+	if rk.rng != nil {
+		mid, err = rk.jitteredMidpoint(a.value, b.value, rk.valueLimit())
+	} else {
+		mid, err = a.value.midpoint(b.value, rk.valueLimit())
+	}
+	if err != nil {
+		return LexoRank{}, err
+	}
+
+	return rk.finish(a.bucket, mid), nil
+}
+
+// GenBetween returns a new LexoRank that sorts between prev and next.
+// Either pointer may be nil; see the package-level [GenBetween].
+func (rk *Ranker) GenBetween(prev, next *LexoRank) (LexoRank, error) {
+	switch {
+	case prev == nil && next == nil:
+		return rk.Initial(), nil
+	case prev == nil:
+		return rk.genPrev(*next), nil
+	case next == nil:
+		return rk.genNext(*prev), nil
+	default:
+		return rk.Between(*prev, *next)
+	}
+}
+
+// BetweenN returns n ranks that sort strictly between prev and next, in
+// ascending order and evenly spaced across the gap. It uses the shortest
+// value length that fits n distinct ranks, so inserting a block of items does
+// not grow the ranks by one character per item.
+//
+// Either pointer may be nil to stand for the bottom or top of the ranking
+// space. Both ranks must be in the same bucket. If n ranks do not fit within
+// the max length, [ErrRankExhausted] is returned.
+func (rk *Ranker) BetweenN(prev, next *LexoRank, n int) ([]LexoRank, error) {
+	return rk.spread(prev, next, n, rk.maxLength)
+}
+
+// spread implements [Ranker.BetweenN] with ranks limited to maxLength
+// characters, including the replica tag.
+func (rk *Ranker) spread(prev, next *LexoRank, n, maxLength int) ([]LexoRank, error) {
+	if n < 0 {
+		return nil, fmt.Errorf("gexorank: cannot generate %d ranks", n)
+	}
+	if n == 0 {
+		return nil, nil
+	}
+
+	bucket := Bucket0
+	var lo, hi string
+	if prev != nil {
+		bucket, lo = prev.bucket, prev.value.value
+	}
+	if next != nil {
+		if prev != nil && prev.bucket != next.bucket {
+			return nil, fmt.Errorf("gexorank: cannot compute ranks across buckets %s and %s", prev.bucket, next.bucket)
+		}
+		bucket, hi = next.bucket, next.value.value
+	}
+	if prev != nil && next != nil && prev.CompareTo(*next) >= 0 {
+		return nil, fmt.Errorf("gexorank: prev %s must sort before next %s", prev, next)
+	}
+	for _, r := range []*LexoRank{prev, next} {
+		if r != nil && !r.value.Alphabet().equal(rk.alpha) {
+			return nil, fmt.Errorf("gexorank: rank %s is not encoded in the ranker's alphabet", r)
+		}
+	}
+
+	values, err := spreadStr(rk.alpha.set, lo, hi, n, maxLength-len(rk.suffix), rk.suffix)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]LexoRank, n)
+	for i, v := range values {
+		result[i] = rk.rank(bucket, newRankValue(v, rk.alpha))
+	}
+	return result, nil
+}
+
+// Rebalance redistributes a sorted slice of ranks evenly in the target
+// bucket using this Ranker's alphabet and default length. See the
+// package-level [Rebalance]. It returns [ErrRankExhausted] when len(ranks)
+// distinct values do not fit within the max length.
+func (rk *Ranker) Rebalance(ranks []LexoRank, bucket Bucket) ([]LexoRank, error) {
+	n := len(ranks)
+	if n == 0 {
+		return nil, nil
+	}
+
+	rb, err := rk.newRebalancer(n, bucket)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]LexoRank, n)
+	for i := range result {
+		result[i] = rb.next()
+	}
+	return result, nil
+}
+
+// rebalancer generates the evenly spaced ranks of [Ranker.Rebalance] one at
+// a time, so callers do not need to hold every rank in memory.
+type rebalancer struct {
+	rk     *Ranker
+	bucket Bucket
+	length int
+	step   *big.Int
+	cur    *big.Int
+}
+
+// newRebalancer prepares the spacing for n ranks in bucket. It returns
+// [ErrRankExhausted] if n distinct values do not fit within the max length.
+func (rk *Ranker) newRebalancer(n int, bucket Bucket) (*rebalancer, error) {
+	set := rk.alpha.set
+
+	// Use the full space for the default length, growing it until every
+	// rank gets a distinct value.
+	length := rk.defaultLength
+	min := strToBigInt(set, strings.Repeat(string(set.Min()), length))
+	max := strToBigInt(set, strings.Repeat(string(set.Max()), length))
+
+	// space = max - min
+	space := new(largeBigInt).Sub(max, min)
+
+	// step = space / (n + 1)
+	divisor := newLargeBigInt(int64(n + 1))
+	step := new(largeBigInt).Div(space, divisor)
+
+	for step.Sign() == 0 && length < rk.maxLength {
+		length++
+		max = strToBigInt(set, strings.Repeat(string(set.Max()), length))
+		space = new(largeBigInt).Sub(max, min)
+		step = new(largeBigInt).Div(space, divisor)
+	}
+
+	if step.Sign() == 0 {
+		return nil, fmt.Errorf("%w: %d ranks do not fit in %d characters", ErrRankExhausted, n, rk.maxLength)
+	}
+	return &rebalancer{rk: rk, bucket: bucket, length: length, step: step, cur: min}, nil
+}
+
+// next returns the following rank: min + step * (i + 1) for the i-th call.
+func (rb *rebalancer) next() LexoRank {
+	rb.cur = new(largeBigInt).Add(rb.cur, rb.step)
+	str := canonicalStr(rb.rk.alpha.set, bigIntToStr(rb.rk.alpha.set, rb.cur, rb.length))
+	return rb.rk.rank(rb.bucket, newRankValue(str, rb.rk.alpha))
+}
+
+// genNext returns a new LexoRank that sorts after r.
+//
+// The value is read at the default length (truncating longer values) and
+// advanced by the step. Near the top of the space the remaining headroom is
+// halved instead; only when every digit is saturated does the width grow by
+// one character. The maximum value at the max length is returned unchanged.
+func (rk *Ranker) genNext(r LexoRank) LexoRank {
+	set := r.value.set()
+	// The value is saturated at every width covered by its leading run of
+	// maximum characters, so start just past it.
+	start := max(rk.defaultLength, leadingRun(r.value.value, set.Max())+1)
+	for width := start; width <= rk.valueLimit(); width++ {
+		if v, ok := stepUpStr(set, r.value.value, width, rk.jitterStep(rk.step)); ok {
+			return rk.finish(r.bucket, v)
+		}
+	}
+	return r
+}
+
+// genPrev returns a new LexoRank that sorts before r. It mirrors genNext,
+// stepping down toward the minimum value. The minimum value (all zeros) is
+// the floor of the ranking space and is returned unchanged.
+func (rk *Ranker) genPrev(r LexoRank) LexoRank {
+	set := r.value.set()
+	if isMinStr(set, r.value.value) {
+		return r
+	}
+	start := max(rk.defaultLength, leadingRun(r.value.value, set.Min())+1)
+	for width := start; width <= rk.valueLimit(); width++ {
+		if v, ok := stepDownStr(set, r.value.value, width, rk.jitterStep(rk.step)); ok {
+			return rk.finish(r.bucket, v)
+		}
+	}
+	return r
+}
+
+// rank builds a LexoRank owned by rk.
+func (rk *Ranker) rank(bucket Bucket, value RankValue) LexoRank {
+	return LexoRank{bucket: bucket, value: value, ranker: rk}
+}
@@ -0,0 +1,283 @@
+package gexorank_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/lupppig/gexorank"
+)
+
+// --- Ranker Tests ---
+
+func TestNewRanker_Defaults(t *testing.T) {
+	rk, err := gexorank.NewRanker()
+	if err != nil {
+		t.Fatalf("NewRanker() error: %v", err)
+	}
+	if rk.Alphabet() != gexorank.Base36 {
+		t.Errorf("Alphabet() = %q, want base36", rk.Alphabet())
+	}
+	if rk.DefaultLength() != gexorank.DefaultLength || rk.MaxLength() != gexorank.MaxLength {
+		t.Errorf("lengths = %d/%d, want %d/%d", rk.DefaultLength(), rk.MaxLength(), gexorank.DefaultLength, gexorank.MaxLength)
+	}
+	if rk.BucketCount() != 3 {
+		t.Errorf("BucketCount() = %d, want 3", rk.BucketCount())
+	}
+	if got, want := rk.Initial().String(), gexorank.Initial().String(); got != want {
+		t.Errorf("Initial() = %q, want %q", got, want)
+	}
+}
+
+func TestNewRanker_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []gexorank.Option
+	}{
+		{"nil alphabet", []gexorank.Option{gexorank.WithAlphabet(nil)}},
+		{"zero default length", []gexorank.Option{gexorank.WithDefaultLength(0)}},
+		{"max below default", []gexorank.Option{gexorank.WithDefaultLength(8), gexorank.WithMaxLength(4)}},
+		{"one bucket", []gexorank.Option{gexorank.WithBucketCount(1)}},
+		{"too many buckets", []gexorank.Option{gexorank.WithBucketCount(11)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := gexorank.NewRanker(tt.opts...); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestRanker_MaxLengthEnforced(t *testing.T) {
+	rk, err := gexorank.NewRanker(gexorank.WithDefaultLength(4), gexorank.WithMaxLength(8))
+	if err != nil {
+		t.Fatalf("NewRanker error: %v", err)
+	}
+	a, _ := rk.Parse("0|aaaa")
+	b, _ := rk.Parse("0|aaab")
+
+	var exhausted bool
+	for i := 0; i < 100; i++ {
+		mid, err := rk.Between(a, b)
+		if errors.Is(err, gexorank.ErrRankExhausted) {
+			exhausted = true
+			break
+		}
+		if err != nil {
+			t.Fatalf("Between error: %v", err)
+		}
+		if mid.Len() > 8 {
+			t.Fatalf("mid %q exceeds max length 8", mid)
+		}
+		b = mid
+	}
+	if !exhausted {
+		t.Error("expected ErrRankExhausted with max length 8")
+	}
+
+	// Package-level Between enforces the limits of the ranks' Ranker.
+	if _, err := gexorank.Between(a, b); !errors.Is(err, gexorank.ErrRankExhausted) {
+		t.Errorf("Between() error = %v, want ErrRankExhausted", err)
+	}
+
+	if _, err := rk.Parse("0|aaaaaaaaa"); err == nil {
+		t.Error("Parse should reject values longer than max length")
+	}
+	if got := a.MaxLen(); got != 8 {
+		t.Errorf("MaxLen() = %d, want 8", got)
+	}
+	if !a.NeedsRebalance(0.5) {
+		t.Error("Len 4 of MaxLen 8 should need rebalance at 0.5")
+	}
+}
+
+func TestRanker_Base62(t *testing.T) {
+	rk, err := gexorank.NewRanker(gexorank.WithAlphabet(gexorank.Base62), gexorank.WithDefaultLength(4))
+	if err != nil {
+		t.Fatalf("NewRanker error: %v", err)
+	}
+	first := rk.Initial()
+	if first.String() != "0|VVVV" {
+		t.Errorf("Initial() = %q, want 0|VVVV", first)
+	}
+	next, err := rk.GenBetween(&first, nil)
+	if err != nil {
+		t.Fatalf("GenBetween error: %v", err)
+	}
+	mid, err := rk.Between(first, next)
+	if err != nil {
+		t.Fatalf("Between error: %v", err)
+	}
+	for _, r := range []gexorank.LexoRank{next, mid} {
+		if _, err := rk.Parse(r.String()); err != nil {
+			t.Errorf("Parse(%q) error: %v", r, err)
+		}
+	}
+	if !(first.String() < mid.String() && mid.String() < next.String()) {
+		t.Errorf("byte-wise order broken: %q, %q, %q", first, mid, next)
+	}
+	if _, err := gexorank.Parse(next.String()); err == nil {
+		t.Errorf("default Parse should reject base62 value %q", next)
+	}
+}
+
+func TestRanker_Decode(t *testing.T) {
+	rk, err := gexorank.NewRanker(gexorank.WithAlphabet(gexorank.Base62), gexorank.WithDefaultLength(4), gexorank.WithMaxLength(8))
+	if err != nil {
+		t.Fatalf("NewRanker error: %v", err)
+	}
+	const s = "0|VZZ"
+
+	var zero gexorank.LexoRank
+	if err := zero.Scan(s); err == nil {
+		t.Errorf("Scan(%q) into a zero rank should parse as base36 and fail", s)
+	}
+
+	var scanned gexorank.LexoRank
+	if err := rk.Scanner(&scanned).Scan([]byte(s)); err != nil {
+		t.Fatalf("Scanner().Scan error: %v", err)
+	}
+	if scanned.String() != s || scanned.MaxLen() != 8 {
+		t.Errorf("Scanner().Scan = %q with MaxLen %d, want %q with 8", scanned, scanned.MaxLen(), s)
+	}
+	if err := rk.Scanner(&scanned).Scan(12345); err == nil {
+		t.Error("Scanner().Scan(int) should return error")
+	}
+
+	bound := rk.Min()
+	if err := bound.Scan(s); err != nil || bound.String() != s {
+		t.Errorf("Scan into a bound rank = %q, %v, want %q", bound, err, s)
+	}
+
+	item := struct{ Rank gexorank.LexoRank }{Rank: rk.Min()}
+	if err := json.Unmarshal([]byte(`{"Rank":"`+s+`"}`), &item); err != nil || item.Rank.String() != s {
+		t.Errorf("UnmarshalJSON into a bound rank = %q, %v, want %q", item.Rank, err, s)
+	}
+
+	text := rk.Min()
+	if err := text.UnmarshalText([]byte(s)); err != nil || text.String() != s {
+		t.Errorf("UnmarshalText into a bound rank = %q, %v, want %q", text, err, s)
+	}
+}
+
+func TestRanker_EqualAlphabets(t *testing.T) {
+	newRanker := func() *gexorank.Ranker {
+		a, err := gexorank.NewAlphabet("abcd")
+		if err != nil {
+			t.Fatalf("NewAlphabet error: %v", err)
+		}
+		rk, err := gexorank.NewRanker(gexorank.WithAlphabet(a), gexorank.WithDefaultLength(3))
+		if err != nil {
+			t.Fatalf("NewRanker error: %v", err)
+		}
+		return rk
+	}
+	// Two rankers over separately built but identical alphabets, e.g. one
+	// per request.
+	rk1, rk2 := newRanker(), newRanker()
+	lo, hi := rk1.Min(), rk2.Max()
+
+	if _, err := gexorank.Between(lo, hi); err != nil {
+		t.Errorf("Between error: %v", err)
+	}
+	if _, err := rk2.Between(lo, hi); err != nil {
+		t.Errorf("Ranker.Between error: %v", err)
+	}
+	if _, err := rk2.BetweenN(&lo, &hi, 3); err != nil {
+		t.Errorf("Ranker.BetweenN error: %v", err)
+	}
+}
+
+func TestRanker_Comparable(t *testing.T) {
+	rk, err := gexorank.NewRanker()
+	if err != nil {
+		t.Fatalf("NewRanker error: %v", err)
+	}
+	if rk != gexorank.Default() {
+		t.Error("NewRanker() with no options is not Default()")
+	}
+	a, _ := gexorank.Parse("0|abc")
+	b, _ := rk.Parse("0|abc")
+	if a != b {
+		t.Errorf("Parse(%q) != NewRanker().Parse(%q)", a, b)
+	}
+	if seen := map[gexorank.LexoRank]bool{a: true}; !seen[b] {
+		t.Error("map keyed by LexoRank missed an equal rank")
+	}
+
+	chars := gexorank.Base62.String()
+	alpha, err := gexorank.NewAlphabet(chars)
+	if err != nil {
+		t.Fatalf("NewAlphabet error: %v", err)
+	}
+	if alpha != gexorank.Base62 {
+		t.Error("NewAlphabet(Base62 characters) is not Base62")
+	}
+	rk1, _ := gexorank.NewRanker(gexorank.WithAlphabet(alpha), gexorank.WithMaxLength(64))
+	rk2, _ := gexorank.NewRanker(gexorank.WithMaxLength(64), gexorank.WithAlphabet(gexorank.Base62))
+	if rk1.Initial() != rk2.Initial() {
+		t.Error("ranks of equally configured Rankers are not ==")
+	}
+	if rk3, _ := gexorank.NewRanker(gexorank.WithMaxLength(32)); rk3 == rk1 {
+		t.Error("differently configured Rankers share a pointer")
+	}
+}
+
+func TestRanker_BucketCount(t *testing.T) {
+	rk, err := gexorank.NewRanker(gexorank.WithBucketCount(5))
+	if err != nil {
+		t.Fatalf("NewRanker error: %v", err)
+	}
+	r, err := rk.Parse("4|iiiiii")
+	if err != nil {
+		t.Fatalf("Parse error: %v", err)
+	}
+	if got := r.InNextBucket().Bucket(); got != 0 {
+		t.Errorf("InNextBucket() bucket = %v, want 0", got)
+	}
+	if got := r.InPrevBucket().Bucket(); got != 3 {
+		t.Errorf("InPrevBucket() bucket = %v, want 3", got)
+	}
+	if _, err := rk.Parse("5|iiiiii"); err == nil {
+		t.Error("Parse should reject bucket 5 with 5 buckets")
+	}
+	if _, err := gexorank.Parse("4|iiiiii"); err == nil {
+		t.Error("default Parse should reject bucket 4")
+	}
+}
+
+func TestRanker_Rebalance(t *testing.T) {
+	rk, err := gexorank.NewRanker(gexorank.WithDefaultLength(2))
+	if err != nil {
+		t.Fatalf("NewRanker error: %v", err)
+	}
+	ranks := make([]gexorank.LexoRank, 2000)
+	rebalanced, err := rk.Rebalance(ranks, gexorank.Bucket1)
+	if err != nil {
+		t.Fatalf("Rebalance error: %v", err)
+	}
+	if rebalanced[0].Len() != 3 {
+		t.Errorf("Len() = %d, want 3 (grown to fit 2000 ranks)", rebalanced[0].Len())
+	}
+	for i := 1; i < len(rebalanced); i++ {
+		if rebalanced[i].CompareTo(rebalanced[i-1]) <= 0 {
+			t.Fatalf("rebalanced[%d]=%q <= rebalanced[%d]=%q", i, rebalanced[i], i-1, rebalanced[i-1])
+		}
+	}
+}
+
+func ExampleNewRanker() {
+	rk, err := gexorank.NewRanker(
+		gexorank.WithAlphabet(gexorank.Base62),
+		gexorank.WithDefaultLength(4),
+		gexorank.WithMaxLength(64),
+	)
+	if err != nil {
+		fmt.Println("error:", err)
+		return
+	}
+	fmt.Println(rk.Initial())
+	// Output: 0|VVVV
+}
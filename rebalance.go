@@ -0,0 +1,284 @@
+package gexorank
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"math/big"
+)
+
+// RebalanceRange redistributes a contiguous window of ranks strictly between
+// its fixed outer neighbors, prev and next. Unlike [Rebalance], which spreads
+// every rank across the whole ranking space, only the window is rewritten:
+// the neighbors and every rank outside them keep their values.
+//
+// The window must be sorted in ascending order and lie strictly between prev
+// and next, in their bucket. Either neighbor may be nil when the window
+// starts or ends the list. The new ranks use the shortest length that fits
+// the window in the gap (see [BetweenN]); if they do not fit within the max
+// length, [ErrRankExhausted] is returned and the caller should widen the
+// window or fall back to a full [Rebalance].
+func RebalanceRange(window []LexoRank, prev, next *LexoRank) ([]LexoRank, error) {
+	return rankerOf(prev, next).RebalanceRange(window, prev, next)
+}
+
+// RebalanceRange redistributes window between prev and next using this
+// Ranker's alphabet and limits. See the package-level [RebalanceRange].
+func (rk *Ranker) RebalanceRange(window []LexoRank, prev, next *LexoRank) ([]LexoRank, error) {
+	if len(window) == 0 {
+		return nil, nil
+	}
+
+	for i, r := range window {
+		if i > 0 && r.CompareTo(window[i-1]) <= 0 {
+			return nil, fmt.Errorf("gexorank: window is not sorted: %s at index %d follows %s", r, i, window[i-1])
+		}
+		for _, n := range []*LexoRank{prev, next} {
+			if n != nil && n.bucket != r.bucket {
+				return nil, fmt.Errorf("gexorank: window rank %s is not in neighbor bucket %s", r, n.bucket)
+			}
+		}
+	}
+	if prev != nil && window[0].CompareTo(*prev) <= 0 {
+		return nil, fmt.Errorf("gexorank: window starts at %s, not after prev %s", window[0], prev)
+	}
+	if last := window[len(window)-1]; next != nil && last.CompareTo(*next) >= 0 {
+		return nil, fmt.Errorf("gexorank: window ends at %s, not before next %s", last, next)
+	}
+
+	result, err := rk.BetweenN(prev, next, len(window))
+	if err != nil {
+		return nil, err
+	}
+
+	// With both neighbors missing, BetweenN cannot tell which bucket the
+	// window belongs to.
+	for i := range result {
+		result[i].bucket = window[0].bucket
+	}
+	return result, nil
+}
+
+// RebalanceChange is a single rank rewrite: the rank at Index changes from
+// Old to New.
+type RebalanceChange struct {
+	Index int
+	Old   LexoRank
+	New   LexoRank
+}
+
+// PlanRebalance returns the changes needed to bring every rank in a sorted
+// slice down to at most maxLen characters, while preserving the order. Ranks
+// that are already short enough keep their values unless a neighboring run of
+// long ranks does not fit between them; each run is then widened by short
+// ranks, doubling the number taken each time, until it fits. The changes are
+// ordered by index, so a migration only has to UPDATE the rows they name.
+//
+// The ranks must be in one bucket and strictly ascending. If the ranks cannot
+// all fit within maxLen, [ErrRankExhausted] is returned and a full
+// [Rebalance] is needed.
+//
+// The [Ranker] that produced the first rank determines the alphabet.
+func PlanRebalance(ranks []LexoRank, maxLen int) ([]RebalanceChange, error) {
+	if len(ranks) == 0 {
+		return nil, nil
+	}
+	return ranks[0].rk().PlanRebalance(ranks, maxLen)
+}
+
+// PlanRebalance plans a minimal rebalance using this Ranker's alphabet. See
+// the package-level [PlanRebalance].
+func (rk *Ranker) PlanRebalance(ranks []LexoRank, maxLen int) ([]RebalanceChange, error) {
+	if maxLen < 1 || maxLen > rk.maxLength {
+		return nil, fmt.Errorf("gexorank: length threshold must be between 1 and %d, got %d", rk.maxLength, maxLen)
+	}
+	for i := 1; i < len(ranks); i++ {
+		if ranks[i].bucket != ranks[0].bucket {
+			return nil, fmt.Errorf("gexorank: rank %s is not in bucket %s", ranks[i], ranks[0].bucket)
+		}
+		if ranks[i].CompareTo(ranks[i-1]) <= 0 {
+			return nil, fmt.Errorf("gexorank: ranks are not sorted: %s at index %d follows %s", ranks[i], i, ranks[i-1])
+		}
+	}
+
+	// Every window lies inside the whole list, so if the list does not fit,
+	// no window search can succeed.
+	if !rk.fits(len(ranks), maxLen) {
+		return nil, ErrRankExhausted
+	}
+
+	out := make([]LexoRank, len(ranks))
+	copy(out, ranks)
+
+	// free is the first index that has not been settled by an earlier window.
+	free := 0
+	for start := 0; start < len(out); start++ {
+		if out[start].Len() <= maxLen {
+			continue
+		}
+		end := start + 1
+		for end < len(out) && out[end].Len() > maxLen {
+			end++
+		}
+
+		lo, hi, fresh, err := rk.fitWindow(out, start, end, free, maxLen)
+		if err != nil {
+			return nil, err
+		}
+		copy(out[lo+1:hi], fresh)
+		free = hi
+		start = hi - 1
+	}
+
+	var changes []RebalanceChange
+	for i := range ranks {
+		if out[i].String() != ranks[i].String() {
+			changes = append(changes, RebalanceChange{Index: i, Old: ranks[i], New: out[i]})
+		}
+	}
+	return changes, nil
+}
+
+// fits reports whether n ranks fit between the open ends of the ranking space
+// at maxLen characters.
+func (rk *Ranker) fits(n, maxLen int) bool {
+	width := maxLen - len(rk.suffix)
+	if width < 1 {
+		return n == 0
+	}
+	limit := new(big.Int).Exp(big.NewInt(int64(rk.alpha.Size())), big.NewInt(int64(width)), nil)
+	return limit.Cmp(big.NewInt(int64(n))) > 0
+}
+
+// fitWindow finds a small window around the run ranks[start:end] whose ranks
+// fit within maxLen between its outer neighbors, ranks[lo] and ranks[hi].
+// The window never reaches below free. It returns the neighbors' indexes (-1
+// and len(ranks) stand for open ends) and the new ranks for ranks[lo+1:hi].
+//
+// The number of short ranks taken into the window doubles on each attempt,
+// split evenly between both sides or taken from one side only, so a run
+// needing k extra ranks costs O(log k) spreads.
+func (rk *Ranker) fitWindow(ranks []LexoRank, start, end, free, maxLen int) (lo, hi int, fresh []LexoRank, err error) {
+	maxLeft := start - free
+	maxRight := len(ranks) - end
+	for extra := 0; ; extra = min(max(1, 2*extra), maxLeft+maxRight) {
+		// Candidate counts taken from the left: an even split, then all
+		// from one side, each clamped to what that side has.
+		even := min(max(extra/2, extra-maxRight), maxLeft)
+		for _, left := range []int{even, min(extra, maxLeft), extra - min(extra, maxRight)} {
+			lo, hi = start-1-left, end+extra-left
+			var prev, next *LexoRank
+			if lo >= 0 {
+				prev = &ranks[lo]
+			}
+			if hi < len(ranks) {
+				next = &ranks[hi]
+			}
+			fresh, err = rk.spread(prev, next, hi-lo-1, maxLen)
+			if err == nil {
+				for i := range fresh {
+					fresh[i].bucket = ranks[start].bucket
+				}
+				return lo, hi, fresh, nil
+			}
+			if !errors.Is(err, ErrRankExhausted) {
+				return 0, 0, nil, err
+			}
+		}
+		if extra == maxLeft+maxRight {
+			return 0, 0, nil, ErrRankExhausted
+		}
+	}
+}
+
+// RebalanceSeq returns an iterator over the n ranks [Rebalance] would assign
+// to a list of n items in bucket, paired with their positions. The ranks are
+// computed one at a time, so a table too large to load can be rewritten from
+// a cursor without materializing a slice. It uses the [Default] Ranker.
+//
+// The spacing is planned before the iterator is returned, so
+// [ErrRankExhausted] is reported up front if n ranks do not fit within the
+// max length.
+func RebalanceSeq(n int, bucket Bucket) (iter.Seq2[int, LexoRank], error) {
+	return defaultRanker.RebalanceSeq(n, bucket)
+}
+
+// RebalanceSeq streams the ranks of [Ranker.Rebalance] for n items. See the
+// package-level [RebalanceSeq].
+func (rk *Ranker) RebalanceSeq(n int, bucket Bucket) (iter.Seq2[int, LexoRank], error) {
+	if n <= 0 {
+		return func(func(int, LexoRank) bool) {}, nil
+	}
+	plan, err := rk.newRebalancer(n, bucket)
+	if err != nil {
+		return nil, err
+	}
+	return func(yield func(int, LexoRank) bool) {
+		// Each range starts from the plan, so the sequence can be reused.
+		rb := *plan
+		rb.cur = new(big.Int).Set(plan.cur)
+		for i := range n {
+			if !yield(i, rb.next()) {
+				return
+			}
+		}
+	}, nil
+}
+
+// RebalanceEach rebalances a stream of n sorted ranks into bucket, calling fn
+// with each old rank and its replacement in order. The result matches
+// [Rebalance] on the same ranks, but only one rank is held at a time.
+//
+// Iteration stops at the first error from fn, which is returned. An error is
+// also returned if ranks is not strictly ascending or does not yield exactly
+// n ranks; fn has then already been called for the ranks before the problem.
+// If n ranks do not fit within the max length, [ErrRankExhausted] is returned
+// before fn is called.
+//
+// The [Ranker] that produced the first rank determines the alphabet and length.
+func RebalanceEach(ranks iter.Seq[LexoRank], n int, bucket Bucket, fn func(old, fresh LexoRank) error) error {
+	return rebalanceEach(nil, ranks, n, bucket, fn)
+}
+
+// RebalanceEach streams a rebalance using this Ranker's alphabet and default
+// length. See the package-level [RebalanceEach].
+func (rk *Ranker) RebalanceEach(ranks iter.Seq[LexoRank], n int, bucket Bucket, fn func(old, fresh LexoRank) error) error {
+	return rebalanceEach(rk, ranks, n, bucket, fn)
+}
+
+// rebalanceEach implements RebalanceEach. A nil rk is taken from the first rank.
+func rebalanceEach(rk *Ranker, ranks iter.Seq[LexoRank], n int, bucket Bucket, fn func(old, fresh LexoRank) error) error {
+	if n < 0 {
+		return fmt.Errorf("gexorank: cannot rebalance %d ranks", n)
+	}
+
+	var rb *rebalancer
+	var prev LexoRank
+	i := 0
+	for old := range ranks {
+		if i == n {
+			return fmt.Errorf("gexorank: ranks yielded more than %d values", n)
+		}
+		if i > 0 && old.CompareTo(prev) <= 0 {
+			return fmt.Errorf("gexorank: ranks are not sorted: %s at index %d follows %s", old, i, prev)
+		}
+		if rb == nil {
+			if rk == nil {
+				rk = old.rk()
+			}
+			var err error
+			if rb, err = rk.newRebalancer(n, bucket); err != nil {
+				return err
+			}
+		}
+		if err := fn(old, rb.next()); err != nil {
+			return err
+		}
+		prev = old
+		i++
+	}
+	if i < n {
+		return fmt.Errorf("gexorank: ranks yielded %d values, want %d", i, n)
+	}
+	return nil
+}
@@ -0,0 +1,463 @@
+package gexorank_test
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/lupppig/gexorank"
+)
+
+// --- RebalanceRange Tests ---
+
+// congested returns n ranks squeezed between prev and next by repeated
+// midpoint inserts, the way long ranks build up in practice.
+func congested(t *testing.T, prev, next gexorank.LexoRank, n int) []gexorank.LexoRank {
+	t.Helper()
+	ranks := make([]gexorank.LexoRank, 0, n)
+	hi := next
+	for range n {
+		mid, err := gexorank.Between(prev, hi)
+		if err != nil {
+			t.Fatalf("Between error: %v", err)
+		}
+		ranks = append(ranks, mid)
+		hi = mid
+	}
+	gexorank.Sort(ranks)
+	return ranks
+}
+
+func TestRebalanceRange(t *testing.T) {
+	prev := mustParse(t, "0|hzzzzz")
+	next := mustParse(t, "0|i00001")
+	window := congested(t, prev, next, 40)
+	if window[0].Len() < 12 {
+		t.Fatalf("setup: window ranks only %d chars", window[0].Len())
+	}
+
+	fresh, err := gexorank.RebalanceRange(window, &prev, &next)
+	if err != nil {
+		t.Fatalf("RebalanceRange error: %v", err)
+	}
+	if len(fresh) != len(window) {
+		t.Fatalf("got %d ranks, want %d", len(fresh), len(window))
+	}
+	for i, r := range fresh {
+		if r.Len() > 8 {
+			t.Errorf("fresh[%d] = %q is %d chars, want at most 8", i, r, r.Len())
+		}
+		if r.Bucket() != prev.Bucket() {
+			t.Errorf("fresh[%d] bucket = %v, want %v", i, r.Bucket(), prev.Bucket())
+		}
+		if r.CompareTo(prev) <= 0 || r.CompareTo(next) >= 0 {
+			t.Fatalf("fresh[%d] = %q not between %q and %q", i, r, prev, next)
+		}
+		if i > 0 && r.CompareTo(fresh[i-1]) <= 0 {
+			t.Fatalf("fresh[%d]=%q <= fresh[%d]=%q", i, r, i-1, fresh[i-1])
+		}
+	}
+}
+
+func TestRebalanceRange_OpenEnds(t *testing.T) {
+	window := []gexorank.LexoRank{
+		mustParse(t, "2|00000001"),
+		mustParse(t, "2|000000011"),
+		mustParse(t, "2|00000002"),
+	}
+	next := mustParse(t, "2|1")
+
+	fresh, err := gexorank.RebalanceRange(window, nil, &next)
+	if err != nil {
+		t.Fatalf("RebalanceRange error: %v", err)
+	}
+	for i, r := range fresh {
+		if r.Bucket() != gexorank.Bucket2 || r.CompareTo(next) >= 0 {
+			t.Errorf("fresh[%d] = %q, want bucket 2 and before %q", i, r, next)
+		}
+	}
+
+	fresh, err = gexorank.RebalanceRange(window, nil, nil)
+	if err != nil {
+		t.Fatalf("RebalanceRange error: %v", err)
+	}
+	if fresh[0].Bucket() != gexorank.Bucket2 {
+		t.Errorf("bucket = %v, want window bucket 2", fresh[0].Bucket())
+	}
+}
+
+func TestRebalanceRange_Invalid(t *testing.T) {
+	a := mustParse(t, "0|a")
+	b := mustParse(t, "0|b")
+	c := mustParse(t, "0|c")
+	d := mustParse(t, "0|d")
+	other := mustParse(t, "1|b")
+
+	tests := []struct {
+		name       string
+		window     []gexorank.LexoRank
+		prev, next *gexorank.LexoRank
+	}{
+		{"unsorted", []gexorank.LexoRank{c, b}, &a, &d},
+		{"duplicate", []gexorank.LexoRank{b, b}, &a, &d},
+		{"before prev", []gexorank.LexoRank{a, b}, &a, &d},
+		{"after next", []gexorank.LexoRank{b, d}, &a, &d},
+		{"other bucket", []gexorank.LexoRank{other}, &a, &d},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := gexorank.RebalanceRange(tt.window, tt.prev, tt.next); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+
+	if fresh, err := gexorank.RebalanceRange(nil, &a, &d); err != nil || fresh != nil {
+		t.Errorf("RebalanceRange(nil) = %v, %v; want nil, nil", fresh, err)
+	}
+}
+
+func TestRebalanceRange_Exhausted(t *testing.T) {
+	rk, err := gexorank.NewRanker(gexorank.WithDefaultLength(2), gexorank.WithMaxLength(4))
+	if err != nil {
+		t.Fatalf("NewRanker error: %v", err)
+	}
+	prev, _ := rk.Parse("0|aaaa")
+	next, _ := rk.Parse("0|aaab")
+	// The window is longer than the ranker allows, so parse it with the default.
+	window := []gexorank.LexoRank{mustParse(t, "0|aaaa1"), mustParse(t, "0|aaaa2")}
+	if _, err := rk.RebalanceRange(window, &prev, &next); !errors.Is(err, gexorank.ErrRankExhausted) {
+		t.Errorf("RebalanceRange error = %v, want ErrRankExhausted", err)
+	}
+}
+
+func ExampleRebalanceRange() {
+	prev, _ := gexorank.Parse("0|a")
+	next, _ := gexorank.Parse("0|b")
+	window := []gexorank.LexoRank{}
+	for _, s := range []string{"0|a000000001", "0|a0000000011", "0|a000000002"} {
+		r, _ := gexorank.Parse(s)
+		window = append(window, r)
+	}
+
+	fresh, _ := gexorank.RebalanceRange(window, &prev, &next)
+	for _, r := range fresh {
+		fmt.Println(r)
+	}
+	// Output:
+	// 0|a9
+	// 0|ai
+	// 0|ar
+}
+
+// --- PlanRebalance Tests ---
+
+// applyPlan returns ranks with changes applied, checking each change's Old.
+func applyPlan(t *testing.T, ranks []gexorank.LexoRank, changes []gexorank.RebalanceChange) []gexorank.LexoRank {
+	t.Helper()
+	out := append([]gexorank.LexoRank(nil), ranks...)
+	for i, c := range changes {
+		if i > 0 && c.Index <= changes[i-1].Index {
+			t.Fatalf("changes not ordered by index: %d after %d", c.Index, changes[i-1].Index)
+		}
+		if c.Old.String() != ranks[c.Index].String() {
+			t.Fatalf("change %d: Old = %q, want %q", i, c.Old, ranks[c.Index])
+		}
+		out[c.Index] = c.New
+	}
+	return out
+}
+
+func TestPlanRebalance(t *testing.T) {
+	tests := []struct {
+		name        string
+		ranks       []string
+		maxLen      int
+		wantChanged []int
+	}{
+		{"already short", []string{"0|a", "0|b", "0|c"}, 6, nil},
+		{"one long rank", []string{"0|a", "0|b", "0|b00000001", "0|c"}, 6, []int{2}},
+		{"long run", []string{"0|a", "0|b0000001", "0|b0000002", "0|b0000003", "0|c"}, 3, []int{1, 2, 3}},
+		{"open end", []string{"0|a", "0|zzzzzzzz1"}, 4, []int{1}},
+		{"no room between neighbors", []string{"0|a", "0|a1", "0|a10001", "0|a2", "0|b"}, 2, []int{2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ranks []gexorank.LexoRank
+			for _, s := range tt.ranks {
+				ranks = append(ranks, mustParse(t, s))
+			}
+
+			changes, err := gexorank.PlanRebalance(ranks, tt.maxLen)
+			if err != nil {
+				t.Fatalf("PlanRebalance error: %v", err)
+			}
+			var changed []int
+			for _, c := range changes {
+				changed = append(changed, c.Index)
+			}
+			if fmt.Sprint(changed) != fmt.Sprint(tt.wantChanged) {
+				t.Errorf("changed indexes = %v, want %v", changed, tt.wantChanged)
+			}
+
+			out := applyPlan(t, ranks, changes)
+			for i, r := range out {
+				if r.Len() > tt.maxLen {
+					t.Errorf("out[%d] = %q exceeds %d chars", i, r, tt.maxLen)
+				}
+				if i > 0 && r.CompareTo(out[i-1]) <= 0 {
+					t.Errorf("out[%d]=%q <= out[%d]=%q", i, r, i-1, out[i-1])
+				}
+			}
+		})
+	}
+}
+
+func TestPlanRebalance_Invalid(t *testing.T) {
+	a := mustParse(t, "0|a")
+	b := mustParse(t, "0|b")
+	other := mustParse(t, "1|c")
+
+	if _, err := gexorank.PlanRebalance([]gexorank.LexoRank{b, a}, 6); err == nil {
+		t.Error("unsorted ranks should return error")
+	}
+	if _, err := gexorank.PlanRebalance([]gexorank.LexoRank{a, other}, 6); err == nil {
+		t.Error("ranks across buckets should return error")
+	}
+	if _, err := gexorank.PlanRebalance([]gexorank.LexoRank{a}, 0); err == nil {
+		t.Error("zero threshold should return error")
+	}
+
+	// 37 ranks cannot fit in one base36 character.
+	var ranks []gexorank.LexoRank
+	for i := range 37 {
+		ranks = append(ranks, mustParse(t, fmt.Sprintf("0|i%03d", i)))
+	}
+	if _, err := gexorank.PlanRebalance(ranks, 1); !errors.Is(err, gexorank.ErrRankExhausted) {
+		t.Errorf("PlanRebalance error = %v, want ErrRankExhausted", err)
+	}
+}
+
+func TestPlanRebalance_Dense(t *testing.T) {
+	// Every two-character value, then a run of long ranks in the middle that
+	// needs many short neighbors to make room at three characters.
+	var ranks []gexorank.LexoRank
+	for i := 1; i < 36*36; i++ {
+		v := fmt.Sprintf("%02s", strconv.FormatInt(int64(i), 36))
+		ranks = append(ranks, mustParse(t, "0|"+v))
+		if v == "hh" {
+			for j := range 200 {
+				ranks = append(ranks, mustParse(t, fmt.Sprintf("0|hh%05d", j+1)))
+			}
+		}
+	}
+
+	changes, err := gexorank.PlanRebalance(ranks, 3)
+	if err != nil {
+		t.Fatalf("PlanRebalance error: %v", err)
+	}
+	if len(changes) >= len(ranks)/2 {
+		t.Errorf("PlanRebalance changed %d of %d ranks", len(changes), len(ranks))
+	}
+	out := applyPlan(t, ranks, changes)
+	for i, r := range out {
+		if r.Len() > 3 {
+			t.Errorf("out[%d] = %q exceeds 3 chars", i, r)
+		}
+		if i > 0 && r.CompareTo(out[i-1]) <= 0 {
+			t.Errorf("out[%d]=%q <= out[%d]=%q", i, r, i-1, out[i-1])
+		}
+	}
+
+	// One long rank among every two-character value is one more rank than
+	// fits in two characters.
+	ranks = ranks[:0]
+	for i := 1; i < 36*36; i++ {
+		v := fmt.Sprintf("%02s", strconv.FormatInt(int64(i), 36))
+		ranks = append(ranks, mustParse(t, "0|"+v))
+		if v == "hh" {
+			ranks = append(ranks, mustParse(t, "0|hh1"))
+		}
+	}
+	if _, err := gexorank.PlanRebalance(ranks, 2); !errors.Is(err, gexorank.ErrRankExhausted) {
+		t.Errorf("PlanRebalance error = %v, want ErrRankExhausted", err)
+	}
+}
+
+func ExamplePlanRebalance() {
+	var ranks []gexorank.LexoRank
+	for _, s := range []string{"0|a", "0|a000000001", "0|a000000002", "0|b"} {
+		r, _ := gexorank.Parse(s)
+		ranks = append(ranks, r)
+	}
+
+	changes, _ := gexorank.PlanRebalance(ranks, 6)
+	for _, c := range changes {
+		fmt.Println(c.Index, c.Old, "->", c.New)
+	}
+	// Output:
+	// 1 0|a000000001 -> 0|ac
+	// 2 0|a000000002 -> 0|ao
+}
+
+// --- Streaming Rebalance Tests ---
+
+func TestRebalanceSeq_MatchesRebalance(t *testing.T) {
+	for _, n := range []int{1, 7, 2000} {
+		ranks := make([]gexorank.LexoRank, n)
+		want, err := gexorank.Rebalance(ranks, gexorank.Bucket1)
+		if err != nil {
+			t.Fatalf("n=%d: Rebalance error: %v", n, err)
+		}
+
+		seq, err := gexorank.RebalanceSeq(n, gexorank.Bucket1)
+		if err != nil {
+			t.Fatalf("n=%d: RebalanceSeq error: %v", n, err)
+		}
+		i := 0
+		for idx, r := range seq {
+			if idx != i || r.String() != want[i].String() {
+				t.Fatalf("n=%d: RebalanceSeq yielded %d, %q; want %d, %q", n, idx, r, i, want[i])
+			}
+			i++
+		}
+		if i != n {
+			t.Errorf("n=%d: RebalanceSeq yielded %d ranks", n, i)
+		}
+		for _, r := range seq {
+			if r.String() != want[0].String() {
+				t.Errorf("n=%d: second range started at %q, want %q", n, r, want[0])
+			}
+			break
+		}
+	}
+
+	seq, err := gexorank.RebalanceSeq(0, gexorank.Bucket0)
+	if err != nil {
+		t.Fatalf("RebalanceSeq(0) error: %v", err)
+	}
+	for range seq {
+		t.Error("RebalanceSeq(0) should yield nothing")
+	}
+}
+
+func TestRebalanceEach(t *testing.T) {
+	ranks := []gexorank.LexoRank{
+		mustParse(t, "0|a"),
+		mustParse(t, "0|a0000001"),
+		mustParse(t, "0|a00000011"),
+		mustParse(t, "0|b"),
+	}
+	want, err := gexorank.Rebalance(ranks, gexorank.Bucket1)
+	if err != nil {
+		t.Fatalf("Rebalance error: %v", err)
+	}
+
+	i := 0
+	err = gexorank.RebalanceEach(slices.Values(ranks), len(ranks), gexorank.Bucket1, func(old, fresh gexorank.LexoRank) error {
+		if old.String() != ranks[i].String() || fresh.String() != want[i].String() {
+			t.Errorf("call %d: got (%q, %q), want (%q, %q)", i, old, fresh, ranks[i], want[i])
+		}
+		i++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("RebalanceEach error: %v", err)
+	}
+	if i != len(ranks) {
+		t.Errorf("fn called %d times, want %d", i, len(ranks))
+	}
+}
+
+func TestRebalanceEach_Errors(t *testing.T) {
+	a := mustParse(t, "0|a")
+	b := mustParse(t, "0|b")
+	noop := func(old, fresh gexorank.LexoRank) error { return nil }
+
+	tests := []struct {
+		name  string
+		ranks []gexorank.LexoRank
+		n     int
+	}{
+		{"too few", []gexorank.LexoRank{a}, 2},
+		{"too many", []gexorank.LexoRank{a, b}, 1},
+		{"unsorted", []gexorank.LexoRank{b, a}, 2},
+		{"negative count", nil, -1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := gexorank.RebalanceEach(slices.Values(tt.ranks), tt.n, gexorank.Bucket1, noop); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+
+	errWrite := errors.New("write failed")
+	calls := 0
+	err := gexorank.RebalanceEach(slices.Values([]gexorank.LexoRank{a, b}), 2, gexorank.Bucket1, func(old, fresh gexorank.LexoRank) error {
+		calls++
+		return errWrite
+	})
+	if !errors.Is(err, errWrite) || calls != 1 {
+		t.Errorf("RebalanceEach = %v after %d calls, want errWrite after 1", err, calls)
+	}
+}
+
+func ExampleRebalanceSeq() {
+	seq, err := gexorank.RebalanceSeq(3, gexorank.Bucket1)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for i, r := range seq {
+		fmt.Println(i, r)
+	}
+	// Output:
+	// 0 1|8zzzzz
+	// 1 1|hzzzzy
+	// 2 1|qzzzzx
+}
+
+func TestRebalance_Exhausted(t *testing.T) {
+	// 36² - 1 values fit in two base36 characters and 35 in one; more ranks
+	// than that cannot be given distinct evenly spaced ranks.
+	tests := []struct {
+		maxLength, n int
+	}{
+		{1, 40},
+		{2, 1300},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprint("max length ", tt.maxLength), func(t *testing.T) {
+			rk, err := gexorank.NewRanker(gexorank.WithDefaultLength(1), gexorank.WithMaxLength(tt.maxLength))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ranks := make([]gexorank.LexoRank, tt.n)
+			for i := range ranks {
+				ranks[i] = rk.Initial()
+			}
+
+			if _, err := rk.Rebalance(ranks, gexorank.Bucket1); !errors.Is(err, gexorank.ErrRankExhausted) {
+				t.Errorf("Ranker.Rebalance error = %v, want ErrRankExhausted", err)
+			}
+			if _, err := gexorank.Rebalance(ranks, gexorank.Bucket1); !errors.Is(err, gexorank.ErrRankExhausted) {
+				t.Errorf("Rebalance error = %v, want ErrRankExhausted", err)
+			}
+
+			calls := 0
+			err = rk.RebalanceEach(slices.Values(ranks), len(ranks), gexorank.Bucket1, func(old, fresh gexorank.LexoRank) error {
+				calls++
+				return nil
+			})
+			if !errors.Is(err, gexorank.ErrRankExhausted) || calls != 0 {
+				t.Errorf("RebalanceEach = %v after %d calls, want ErrRankExhausted after 0", err, calls)
+			}
+			if seq, err := rk.RebalanceSeq(len(ranks), gexorank.Bucket1); seq != nil || !errors.Is(err, gexorank.ErrRankExhausted) {
+				t.Errorf("RebalanceSeq error = %v, want ErrRankExhausted and no sequence", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package gexorank
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+)
+
+// Repair returns the fewest rank reassignments that make every rank distinct,
+// for lists corrupted by concurrent writers that computed the same rank (see
+// [GenBetween]). ranks may be in any order; the changes refer to its indexes
+// and are ordered by index.
+//
+// Items are ordered by rank and, among equal ranks, by tiebreak, which
+// compares the items at two indexes (for example by ID) and must be
+// deterministic so that a rerun gives the same answer. A nil tiebreak keeps
+// equal ranks in slice order. In every run of equal ranks the first item
+// keeps its rank and the others get new ranks spread across the gap up to
+// the next distinct rank. Ranks that differ only by trailing padding ("0|a"
+// and "0|a0") count as equal. If a gap is too small, the smallest window of
+// neighbors that makes room is respaced instead, as in [PlanRebalance].
+//
+// Each bucket is repaired separately. If a bucket has no room left within
+// the max length, [ErrRankExhausted] is returned and a full [Rebalance] is
+// needed.
+//
+// The [Ranker] that produced the first rank determines the alphabet and
+// limits.
+func Repair(ranks []LexoRank, tiebreak func(i, j int) int) ([]RebalanceChange, error) {
+	if len(ranks) == 0 {
+		return nil, nil
+	}
+	return ranks[0].rk().Repair(ranks, tiebreak)
+}
+
+// Repair plans the reassignments that make ranks distinct using this
+// Ranker's alphabet and limits. See the package-level [Repair].
+func (rk *Ranker) Repair(ranks []LexoRank, tiebreak func(i, j int) int) ([]RebalanceChange, error) {
+	if tiebreak == nil {
+		tiebreak = cmp.Compare[int]
+	}
+	order := make([]int, len(ranks))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Or(ranks[a].CompareTo(ranks[b]), tiebreak(a, b))
+	})
+
+	var changes []RebalanceChange
+	for start := 0; start < len(order); {
+		end := start + 1
+		for end < len(order) && ranks[order[end]].bucket == ranks[order[start]].bucket {
+			end++
+		}
+		seg := order[start:end]
+		sorted := make([]LexoRank, len(seg))
+		for i, idx := range seg {
+			sorted[i] = ranks[idx]
+		}
+		fixed, err := rk.repairSorted(sorted)
+		if err != nil {
+			return nil, err
+		}
+		for i, idx := range seg {
+			if fixed[i].String() != ranks[idx].String() {
+				changes = append(changes, RebalanceChange{Index: idx, Old: ranks[idx], New: fixed[i]})
+			}
+		}
+		start = end
+	}
+
+	slices.SortFunc(changes, func(a, b RebalanceChange) int {
+		return cmp.Compare(a.Index, b.Index)
+	})
+	return changes, nil
+}
+
+// repairSorted returns a copy of ranks, which are ascending and in one
+// bucket, with every run of equal ranks respaced so that all are distinct.
+func (rk *Ranker) repairSorted(ranks []LexoRank) ([]LexoRank, error) {
+	out := slices.Clone(ranks)
+
+	// free is the first index that has not been settled by an earlier window.
+	free := 0
+	for start := 0; start < len(out); {
+		end := start + 1
+		for end < len(out) && out[end].CompareTo(out[start]) == 0 {
+			end++
+		}
+		if end-start == 1 {
+			start++
+			continue
+		}
+
+		// Keep the first rank of the run and fit the rest before the next one.
+		var next *LexoRank
+		if end < len(out) {
+			next = &out[end]
+		}
+		fresh, err := rk.spread(&out[start], next, end-start-1, rk.maxLength)
+		if err == nil {
+			copy(out[start+1:end], fresh)
+			start = end
+			continue
+		}
+		if !errors.Is(err, ErrRankExhausted) {
+			return nil, err
+		}
+
+		lo, hi, fresh, err := rk.fitWindow(out, start+1, end, free, rk.maxLength)
+		if err != nil {
+			return nil, err
+		}
+		copy(out[lo+1:hi], fresh)
+		free = hi
+		start = hi
+	}
+	return out, nil
+}
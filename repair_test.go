@@ -0,0 +1,192 @@
+package gexorank_test
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lupppig/gexorank"
+)
+
+// --- Repair Tests ---
+
+// applyRepair applies changes to ranks and returns the item indexes in the
+// resulting order, failing if any two ranks are still equal.
+func applyRepair(t *testing.T, ranks []gexorank.LexoRank, changes []gexorank.RebalanceChange) []int {
+	t.Helper()
+	fixed := slices.Clone(ranks)
+	for _, c := range changes {
+		if fixed[c.Index] != c.Old {
+			t.Fatalf("change %d: Old = %q, want %q", c.Index, c.Old, fixed[c.Index])
+		}
+		fixed[c.Index] = c.New
+	}
+	order := make([]int, len(fixed))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortFunc(order, func(a, b int) int { return fixed[a].CompareTo(fixed[b]) })
+	for k := 1; k < len(order); k++ {
+		if fixed[order[k]].CompareTo(fixed[order[k-1]]) == 0 {
+			t.Fatalf("items %d and %d still share rank %q", order[k-1], order[k], fixed[order[k]])
+		}
+	}
+	if r := gexorank.Validate(sortedRanks(fixed)); !r.OK() {
+		t.Fatalf("repaired list is invalid:\n%s", r)
+	}
+	return order
+}
+
+func sortedRanks(ranks []gexorank.LexoRank) []gexorank.LexoRank {
+	s := slices.Clone(ranks)
+	gexorank.Sort(s)
+	return s
+}
+
+func TestRepair(t *testing.T) {
+	ids := []string{"d", "b", "x", "a", "c", "y"}
+	ranks := parseAll(t, "0|m", "0|m", "0|a", "0|m", "0|n", "0|n0")
+	byID := func(i, j int) int { return cmp.Compare(ids[i], ids[j]) }
+
+	changes, err := gexorank.Repair(ranks, byID)
+	if err != nil {
+		t.Fatalf("Repair error: %v", err)
+	}
+
+	// One run of three 0|m and one of two 0|n: 2 + 1 changes. a keeps 0|m,
+	// c keeps 0|n.
+	var changed []string
+	for _, c := range changes {
+		changed = append(changed, ids[c.Index])
+		if c.New.CompareTo(mustParse(t, "0|m")) <= 0 {
+			t.Errorf("%s moved to %q, below its run", ids[c.Index], c.New)
+		}
+	}
+	if got := strings.Join(changed, ","); got != "d,b,y" {
+		t.Errorf("changed = %s, want d,b,y", got)
+	}
+
+	var got []string
+	for _, i := range applyRepair(t, ranks, changes) {
+		got = append(got, ids[i])
+	}
+	if s := strings.Join(got, ","); s != "x,a,b,d,c,y" {
+		t.Errorf("order = %s, want x,a,b,d,c,y", s)
+	}
+}
+
+func TestRepair_Clean(t *testing.T) {
+	ranks := parseAll(t, "0|c", "0|a", "0|b")
+	changes, err := gexorank.Repair(ranks, nil)
+	if err != nil || len(changes) != 0 {
+		t.Errorf("Repair = %v, %v; want no changes", changes, err)
+	}
+	if changes, err := gexorank.Repair(nil, nil); err != nil || changes != nil {
+		t.Errorf("Repair(nil) = %v, %v; want nil, nil", changes, err)
+	}
+}
+
+func TestRepair_Deterministic(t *testing.T) {
+	ids := []string{"q", "p", "r"}
+	ranks := parseAll(t, "0|k", "0|k", "0|k")
+	byID := func(i, j int) int { return cmp.Compare(ids[i], ids[j]) }
+
+	first, _ := gexorank.Repair(ranks, byID)
+	// The same items listed in another order get the same ranks.
+	perm := []int{2, 0, 1}
+	permRanks := make([]gexorank.LexoRank, len(perm))
+	permIDs := make([]string, len(perm))
+	for i, p := range perm {
+		permRanks[i], permIDs[i] = ranks[p], ids[p]
+	}
+	second, _ := gexorank.Repair(permRanks, func(i, j int) int { return cmp.Compare(permIDs[i], permIDs[j]) })
+
+	result := func(ids []string, changes []gexorank.RebalanceChange) map[string]string {
+		m := make(map[string]string)
+		for _, c := range changes {
+			m[ids[c.Index]] = c.New.String()
+		}
+		return m
+	}
+	if a, b := result(ids, first), result(permIDs, second); fmt.Sprint(a) != fmt.Sprint(b) {
+		t.Errorf("Repair depends on input order: %v vs %v", a, b)
+	}
+}
+
+func TestRepair_TightGap(t *testing.T) {
+	rk, err := gexorank.NewRanker(gexorank.WithDefaultLength(2), gexorank.WithMaxLength(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ranks []gexorank.LexoRank
+	for _, s := range []string{"0|a", "0|a01", "0|a01", "0|a01", "0|a02", "0|b"} {
+		r, _ := rk.Parse(s)
+		ranks = append(ranks, r)
+	}
+
+	// Two items need room between a01 and a02, which have no 3-char value
+	// between them: the window grows to the neighbors.
+	changes, err := rk.Repair(ranks, nil)
+	if err != nil {
+		t.Fatalf("Repair error: %v", err)
+	}
+	order := applyRepair(t, ranks, changes)
+	if fmt.Sprint(order) != "[0 1 2 3 4 5]" {
+		t.Errorf("order = %v, want input order", order)
+	}
+	if len(changes) < 3 {
+		t.Errorf("got %d changes, want the window respaced", len(changes))
+	}
+}
+
+func TestRepair_Buckets(t *testing.T) {
+	ranks := parseAll(t, "1|a", "0|z", "0|z", "1|a")
+	changes, err := gexorank.Repair(ranks, nil)
+	if err != nil {
+		t.Fatalf("Repair error: %v", err)
+	}
+	if len(changes) != 2 {
+		t.Fatalf("got %d changes, want 2", len(changes))
+	}
+	for _, c := range changes {
+		if c.New.Bucket() != c.Old.Bucket() {
+			t.Errorf("index %d moved from bucket %v to %v", c.Index, c.Old.Bucket(), c.New.Bucket())
+		}
+	}
+	applyRepair(t, ranks, changes)
+}
+
+func TestRepair_Exhausted(t *testing.T) {
+	rk, err := gexorank.NewRanker(gexorank.WithDefaultLength(1), gexorank.WithMaxLength(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ranks []gexorank.LexoRank
+	for range 40 {
+		r, _ := rk.Parse("0|5")
+		ranks = append(ranks, r)
+	}
+	if _, err := rk.Repair(ranks, nil); !errors.Is(err, gexorank.ErrRankExhausted) {
+		t.Errorf("Repair of 40 items in 36 values: error = %v, want ErrRankExhausted", err)
+	}
+}
+
+func ExampleRepair() {
+	ids := []string{"task-b", "task-a", "task-c"}
+	var ranks []gexorank.LexoRank
+	for _, s := range []string{"0|i", "0|i", "0|k"} {
+		r, _ := gexorank.Parse(s)
+		ranks = append(ranks, r)
+	}
+
+	changes, _ := gexorank.Repair(ranks, func(i, j int) int {
+		return cmp.Compare(ids[i], ids[j])
+	})
+	for _, c := range changes {
+		fmt.Println(ids[c.Index], c.Old, "->", c.New)
+	}
+	// Output: task-b 0|i -> 0|j
+}
@@ -0,0 +1,99 @@
+package gexorank
+
+import "fmt"
+
+// WithReplica tags every rank the Ranker generates with a replica ID, so that
+// clients generating ranks offline never produce equal ranks, even when they
+// insert into the same gap from the same neighbors.
+//
+// The tag is the ID followed by one character encoding its length, appended
+// after the computed value. The value itself still sorts strictly between its
+// neighbors, so the tag only breaks ties between replicas and never changes
+// the order relative to existing ranks. Tagging applies to [Ranker.Initial],
+// [Ranker.Between], [Ranker.GenBetween], [Ranker.BetweenN],
+// [LexoRank.GenNext] and [LexoRank.GenPrev]; the tag counts towards the max
+// length.
+//
+// The ID must be non-empty, encoded in the Ranker's alphabet and shorter than
+// the alphabet size. Every replica must use a distinct ID.
+func WithReplica(id string) Option {
+	return func(rk *Ranker) {
+		rk.replica = id
+	}
+}
+
+// initReplica validates the replica ID and builds the suffix appended to
+// generated values.
+func (rk *Ranker) initReplica() error {
+	rk.suffix = ""
+	if rk.replica == "" {
+		return nil
+	}
+	if err := rk.alpha.set.Validate(rk.replica); err != nil {
+		return fmt.Errorf("gexorank: invalid replica ID: %w", err)
+	}
+	if len(rk.replica) >= rk.alpha.Size() {
+		return fmt.Errorf("gexorank: replica ID %q must be shorter than %d characters", rk.replica, rk.alpha.Size())
+	}
+
+	// The length character is never the minimum, so tagged values have no
+	// trailing padding and decode unambiguously from the right.
+	suffix := rk.replica + string(rk.alpha.set.ToChar(len(rk.replica)))
+	if rk.defaultLength+len(suffix) > rk.maxLength {
+		return fmt.Errorf("gexorank: replica ID %q does not fit within max length %d", rk.replica, rk.maxLength)
+	}
+	rk.suffix = suffix
+	return nil
+}
+
+// Replica returns the replica ID generated ranks are tagged with, or "" if
+// none is configured.
+func (rk *Ranker) Replica() string {
+	return rk.replica
+}
+
+// valueLimit returns the maximum length of a generated value before the
+// replica tag is appended.
+func (rk *Ranker) valueLimit() int {
+	return rk.maxLength - len(rk.suffix)
+}
+
+// finish builds a generated rank from v. Untagged values are made canonical
+// by trimming trailing '0's; tagged values are kept at full width so the tag
+// is not shifted into a different position, and are canonical because the
+// tag never ends in '0'.
+func (rk *Ranker) finish(bucket Bucket, v string) LexoRank {
+	if rk.suffix == "" {
+		return rk.rank(bucket, newRankValue(canonicalStr(rk.alpha.set, v), rk.alpha))
+	}
+	return rk.rank(bucket, newRankValue(v+rk.suffix, rk.alpha))
+}
+
+// Replica returns the replica ID encoded at the end of r's value and reports
+// whether the value is long enough to hold one. Only ranks generated by a
+// Ranker configured with [WithReplica] carry an ID; for other ranks the
+// result is meaningless.
+func (r LexoRank) Replica() (string, bool) {
+	v := r.value.value
+	if len(v) < 3 {
+		return "", false
+	}
+	n := r.value.set().ToVal(v[len(v)-1])
+	if n < 1 || len(v) < n+2 {
+		return "", false
+	}
+	return v[len(v)-1-n : len(v)-1], true
+}
+
+// StripReplica returns r with its replica tag removed. The result still sorts
+// between the same neighbors as r, but may equal the stripped rank of another
+// replica. If r carries no tag, it is returned unchanged.
+func (r LexoRank) StripReplica() LexoRank {
+	id, ok := r.Replica()
+	if !ok {
+		return r
+	}
+	v := r.value.value
+	r.value = newRankValue(v[:len(v)-len(id)-1], r.value.alpha)
+	return r
+}
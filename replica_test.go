@@ -0,0 +1,178 @@
+package gexorank_test
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"testing"
+
+	"github.com/lupppig/gexorank"
+)
+
+// --- Replica Tests ---
+
+func newReplicaRanker(t *testing.T, id string, opts ...gexorank.Option) *gexorank.Ranker {
+	t.Helper()
+	rk, err := gexorank.NewRanker(append(opts, gexorank.WithReplica(id))...)
+	if err != nil {
+		t.Fatalf("NewRanker error: %v", err)
+	}
+	return rk
+}
+
+func TestWithReplica_Invalid(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []gexorank.Option
+	}{
+		{"invalid character", []gexorank.Option{gexorank.WithReplica("AB")}},
+		{"as long as alphabet", []gexorank.Option{gexorank.WithReplica("0123456789abcdefghijklmnopqrstuvwxyz")}},
+		{"exceeds max length", []gexorank.Option{gexorank.WithMaxLength(8), gexorank.WithReplica("abc")}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := gexorank.NewRanker(tt.opts...); err == nil {
+				t.Error("expected error, got nil")
+			}
+		})
+	}
+}
+
+func TestReplica_SameGapNeverCollides(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b string
+	}{
+		{"wide gap", "0|aaaaaa", "0|zzzzzz"},
+		{"adjacent", "0|iiiiii", "0|iiiiij"},
+		{"tagged neighbors", "0|iiiiiiab2", "0|iiiiiiac2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := mustParse(t, tt.a), mustParse(t, tt.b)
+			rk1 := newReplicaRanker(t, "a")
+			rk2 := newReplicaRanker(t, "b")
+			rk3 := newReplicaRanker(t, "ab")
+
+			var got []gexorank.LexoRank
+			for _, rk := range []*gexorank.Ranker{rk1, rk2, rk3} {
+				mid, err := rk.Between(a, b)
+				if err != nil {
+					t.Fatalf("Between error: %v", err)
+				}
+				if mid.CompareTo(a) <= 0 || mid.CompareTo(b) >= 0 {
+					t.Fatalf("mid %q not between %q and %q", mid, a, b)
+				}
+				got = append(got, mid)
+			}
+			for i := range got {
+				for j := i + 1; j < len(got); j++ {
+					if got[i].CompareTo(got[j]) == 0 {
+						t.Errorf("replicas produced equal ranks %q and %q", got[i], got[j])
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestReplica_NestedStaysWithinMaxLength(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []gexorank.Option
+	}{
+		{"default", nil},
+		{"short max length", []gexorank.Option{gexorank.WithMaxLength(16)}},
+		{"jitter", []gexorank.Option{gexorank.WithJitter(rand.NewPCG(1, 2))}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rk := newReplicaRanker(t, "r5", tt.opts...)
+			lo := rk.Initial()
+			hi := lo.GenNext()
+			for i := 0; ; i++ {
+				if i == 10000 {
+					t.Fatalf("no ErrRankExhausted after %d nested inserts", i)
+				}
+				mid, err := rk.Between(lo, hi)
+				if errors.Is(err, gexorank.ErrRankExhausted) {
+					break
+				}
+				if err != nil {
+					t.Fatalf("insert %d: Between error: %v", i, err)
+				}
+				if mid.CompareTo(lo) <= 0 || mid.CompareTo(hi) >= 0 {
+					t.Fatalf("insert %d: %q not between %q and %q", i, mid, lo, hi)
+				}
+				if mid.Len() > mid.MaxLen() {
+					t.Fatalf("insert %d: %q is longer than %d", i, mid, mid.MaxLen())
+				}
+				if _, err := rk.Parse(mid.String()); err != nil {
+					t.Fatalf("insert %d: Parse(%q) error: %v", i, mid, err)
+				}
+				if i%2 == 0 {
+					hi = mid
+				} else {
+					lo = mid
+				}
+			}
+		})
+	}
+}
+
+func TestReplica_Generators(t *testing.T) {
+	rk := newReplicaRanker(t, "k7")
+	first, _ := rk.GenBetween(nil, nil)
+	next := first.GenNext()
+	prev := first.GenPrev()
+	block, err := rk.BetweenN(&prev, &first, 3)
+	if err != nil {
+		t.Fatalf("BetweenN error: %v", err)
+	}
+
+	ranks := append([]gexorank.LexoRank{prev}, block...)
+	ranks = append(ranks, first, next)
+	for i, r := range ranks {
+		if id, ok := r.Replica(); !ok || id != "k7" {
+			t.Errorf("ranks[%d] = %q: Replica() = %q, %v, want k7", i, r, id, ok)
+		}
+		if i > 0 && r.CompareTo(ranks[i-1]) <= 0 {
+			t.Errorf("ranks[%d]=%q <= ranks[%d]=%q", i, r, i-1, ranks[i-1])
+		}
+	}
+}
+
+func TestStripReplica(t *testing.T) {
+	r := mustParse(t, "0|iiiiiik72")
+	if id, ok := r.Replica(); !ok || id != "k7" {
+		t.Fatalf("Replica() = %q, %v, want k7", id, ok)
+	}
+	if got := r.StripReplica().String(); got != "0|iiiiii" {
+		t.Errorf("StripReplica() = %q, want 0|iiiiii", got)
+	}
+
+	short := mustParse(t, "0|a")
+	if _, ok := short.Replica(); ok {
+		t.Error("Replica() should report false for a one-character value")
+	}
+	if got := short.StripReplica(); got.String() != "0|a" {
+		t.Errorf("StripReplica() = %q, want unchanged 0|a", got)
+	}
+}
+
+func ExampleWithReplica() {
+	phone, _ := gexorank.NewRanker(gexorank.WithReplica("p"))
+	laptop, _ := gexorank.NewRanker(gexorank.WithReplica("l"))
+
+	a, _ := gexorank.Parse("0|aaaaaa")
+	b, _ := gexorank.Parse("0|aaaaab")
+	x, _ := phone.Between(a, b)
+	y, _ := laptop.Between(a, b)
+	fmt.Println(x, y)
+
+	id, _ := x.Replica()
+	fmt.Println(id, x.StripReplica())
+	// Output:
+	// 0|aaaaaaip1 0|aaaaaail1
+	// p 0|aaaaaai
+}
@@ -0,0 +1,337 @@
+// Package replist implements a replicated list ordered by
+// [gexorank.LexoRank], for collaborative editors where every client edits
+// its own copy and exchanges operations with the others.
+//
+// Each item's position is a last-writer-wins register: an [Op] sets an
+// item's rank (inserting or moving it) or removes it, and is stamped with a
+// Lamport [Stamp]. A replica keeps, for every item, the op with the highest
+// stamp it has seen. Because that choice does not depend on the order in
+// which ops arrive, and applying an op twice changes nothing, replicas that
+// have applied the same set of ops hold the same list, whatever the order or
+// duplication of delivery.
+//
+// Ranks are generated with [gexorank.WithReplica], so two replicas inserting
+// or moving different items into the same gap at the same time produce
+// distinct ranks, and a replica never places two items at the same rank,
+// even after the first has moved away. Should two items still end up with
+// equal ranks (for example ranks imported from elsewhere), they are ordered
+// by the replica that placed them and then by item ID, so every replica
+// breaks the tie the same way.
+//
+// Every insert into a gap makes the ranks there longer. Once a gap cannot
+// take another rank within the Ranker's max length, [List.Insert] and
+// [List.Move] return [gexorank.ErrRankExhausted] and change nothing. The
+// package has no way to rebalance a live list, since that would need every
+// replica to agree on the new ranks; place the item at another index, or
+// have all replicas pause and rebuild the list from an agreed snapshot with
+// fresh ranks (for example from [gexorank.Rebalance]).
+//
+// Concurrent edits of the same item resolve to the one with the higher stamp:
+// a move made after seeing a removal brings the item back, and of two
+// concurrent moves the one with the higher counter, or else the higher
+// replica ID, wins. Removed items are kept as tombstones so that late ops
+// cannot resurrect them with an older stamp.
+package replist
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"iter"
+	"slices"
+	"strings"
+
+	"github.com/lupppig/gexorank"
+)
+
+// Stamp is a Lamport timestamp. Stamps are totally ordered by Counter and
+// then by Replica, and every op carries a distinct one.
+type Stamp struct {
+	Counter uint64
+	Replica string
+}
+
+// Compare returns -1, 0 or 1 as s is older than, equal to or newer than o.
+func (s Stamp) Compare(o Stamp) int {
+	return cmp.Or(cmp.Compare(s.Counter, o.Counter), strings.Compare(s.Replica, o.Replica))
+}
+
+// String formats the stamp as "counter@replica".
+func (s Stamp) String() string {
+	return fmt.Sprintf("%d@%s", s.Counter, s.Replica)
+}
+
+// Kind identifies what an [Op] does.
+type Kind uint8
+
+const (
+	// OpPlace sets an item's rank, inserting the item if it is new.
+	OpPlace Kind = iota
+	// OpRemove removes an item.
+	OpRemove
+)
+
+// String returns "place" or "remove".
+func (k Kind) String() string {
+	switch k {
+	case OpPlace:
+		return "place"
+	case OpRemove:
+		return "remove"
+	default:
+		return fmt.Sprintf("Kind(%d)", uint8(k))
+	}
+}
+
+// Op is one edit of a replicated list, as returned by the local edit
+// methods of [List] and passed to [List.Apply] on the other replicas.
+type Op struct {
+	Kind Kind
+	ID   string
+	// Rank is the item's new rank; it is the zero value for OpRemove.
+	Rank  gexorank.LexoRank
+	Stamp Stamp
+}
+
+// String formats the op, e.g. "place card-1 0|iiiiiialice5 at 1@alice".
+func (op Op) String() string {
+	if op.Kind == OpPlace {
+		return fmt.Sprintf("%s %s %s at %s", op.Kind, op.ID, op.Rank, op.Stamp)
+	}
+	return fmt.Sprintf("%s %s at %s", op.Kind, op.ID, op.Stamp)
+}
+
+// List is one replica of a replicated list. Local edits return the op to
+// broadcast; ops from other replicas are merged with [List.Apply].
+//
+// A List is not safe for concurrent use. The zero value is not usable;
+// create one with [New].
+type List struct {
+	replica string
+	rk      *gexorank.Ranker
+	clock   uint64
+	items   map[string]item
+	order   []string // visible IDs, sorted by compare
+
+	// used holds every rank this replica has placed, so that it never hands
+	// out the same rank twice.
+	used map[string]bool
+}
+
+// item is the winning op for one ID.
+type item struct {
+	rank    gexorank.LexoRank
+	stamp   Stamp
+	removed bool
+}
+
+// New returns an empty replica named replica. The name must be unique among
+// the replicas and valid for [gexorank.WithReplica]; opts configure the
+// Ranker that generates its ranks and must be the same on every replica.
+func New(replica string, opts ...gexorank.Option) (*List, error) {
+	if replica == "" {
+		return nil, errors.New("replist: replica name is empty")
+	}
+	rk, err := gexorank.NewRanker(append(slices.Clip(opts), gexorank.WithReplica(replica))...)
+	if err != nil {
+		return nil, fmt.Errorf("replist: %w", err)
+	}
+	return &List{replica: replica, rk: rk, items: make(map[string]item), used: make(map[string]bool)}, nil
+}
+
+// Replica returns the name of this replica.
+func (l *List) Replica() string {
+	return l.replica
+}
+
+// Len returns the number of items in the list.
+func (l *List) Len() int {
+	return len(l.order)
+}
+
+// At returns the ID and rank of the item at index i.
+func (l *List) At(i int) (id string, rank gexorank.LexoRank) {
+	id = l.order[i]
+	return id, l.items[id].rank
+}
+
+// Rank returns the rank of item id and whether it is in the list.
+func (l *List) Rank(id string) (gexorank.LexoRank, bool) {
+	it, ok := l.items[id]
+	if !ok || it.removed {
+		return gexorank.LexoRank{}, false
+	}
+	return it.rank, true
+}
+
+// Index returns the position of item id, or -1 and false if it is not in
+// the list.
+func (l *List) Index(id string) (int, bool) {
+	it, ok := l.items[id]
+	if !ok || it.removed {
+		return -1, false
+	}
+	return l.search(id, it)
+}
+
+// All iterates over the items in order.
+func (l *List) All() iter.Seq2[string, gexorank.LexoRank] {
+	return func(yield func(string, gexorank.LexoRank) bool) {
+		for _, id := range l.order {
+			if !yield(id, l.items[id].rank) {
+				return
+			}
+		}
+	}
+}
+
+// IDs returns the item IDs in order.
+func (l *List) IDs() []string {
+	return slices.Clone(l.order)
+}
+
+// Insert adds item id at index i, which may equal Len() to append, and
+// returns the op to broadcast. It returns [gexorank.ErrConflict] if id is
+// already in the list; a removed ID may be inserted again. It returns
+// [gexorank.ErrRankExhausted] if the gap at i has no room left.
+func (l *List) Insert(id string, i int) (Op, error) {
+	if _, ok := l.Rank(id); ok {
+		return Op{}, fmt.Errorf("%w: %q is already in the list", gexorank.ErrConflict, id)
+	}
+	if i < 0 || i > len(l.order) {
+		return Op{}, fmt.Errorf("replist: index %d out of range [0, %d]", i, len(l.order))
+	}
+	return l.place(id, l.order, i)
+}
+
+// Move moves item id to index i and returns the op to broadcast and whether
+// the item moved; moving an item to its own index produces no op. It returns
+// [gexorank.ErrNotFound] if id is not in the list, and
+// [gexorank.ErrRankExhausted] like Insert.
+func (l *List) Move(id string, i int) (op Op, moved bool, err error) {
+	from, ok := l.Index(id)
+	if !ok {
+		return Op{}, false, fmt.Errorf("%w: %q", gexorank.ErrNotFound, id)
+	}
+	if i < 0 || i >= len(l.order) {
+		return Op{}, false, fmt.Errorf("replist: index %d out of range [0, %d)", i, len(l.order))
+	}
+	if i == from {
+		return Op{}, false, nil
+	}
+	if op, err = l.place(id, slices.Delete(slices.Clone(l.order), from, from+1), i); err != nil {
+		return Op{}, false, err
+	}
+	return op, true, nil
+}
+
+// Remove removes item id and returns the op to broadcast. It returns
+// [gexorank.ErrNotFound] if id is not in the list.
+func (l *List) Remove(id string) (Op, error) {
+	if _, ok := l.Rank(id); !ok {
+		return Op{}, fmt.Errorf("%w: %q", gexorank.ErrNotFound, id)
+	}
+	return l.local(Op{Kind: OpRemove, ID: id})
+}
+
+// place generates a rank for id at index i of order, which does not contain
+// id, and applies the resulting op.
+func (l *List) place(id string, order []string, i int) (Op, error) {
+	var prev, next *gexorank.LexoRank
+	if i > 0 {
+		r := l.items[order[i-1]].rank
+		prev = &r
+	}
+	if i < len(order) {
+		r := l.items[order[i]].rank
+		next = &r
+	}
+	hi := next
+	rank, err := l.rk.GenBetween(prev, hi)
+	for err == nil {
+		if prev != nil && rank.CompareTo(*prev) <= 0 || hi != nil && rank.CompareTo(*hi) >= 0 {
+			// Generating past either end of the ranking space returns the
+			// neighbor unchanged.
+			err = gexorank.ErrRankExhausted
+			break
+		}
+		if !l.used[rank.String()] {
+			break
+		}
+		// The gap's rank was placed before, on an item that another replica
+		// may still show there; step toward prev until the rank is fresh.
+		// Each step narrows the gap, so this ends in ErrRankExhausted at the
+		// latest.
+		stale := rank
+		hi = &stale
+		rank, err = l.rk.GenBetween(prev, hi)
+	}
+	if err != nil {
+		return Op{}, fmt.Errorf("replist: place %q at %d: %w", id, i, err)
+	}
+	return l.local(Op{Kind: OpPlace, ID: id, Rank: rank})
+}
+
+// local stamps an op made on this replica and applies it.
+func (l *List) local(op Op) (Op, error) {
+	op.Stamp = Stamp{Counter: l.clock + 1, Replica: l.replica}
+	if _, err := l.Apply(op); err != nil {
+		return Op{}, err
+	}
+	return op, nil
+}
+
+// Apply merges op, made on this or another replica, into the list and
+// reports whether it changed anything. Ops may arrive in any order and more
+// than once; an op older than the one already applied to its item is
+// ignored.
+func (l *List) Apply(op Op) (changed bool, err error) {
+	switch {
+	case op.ID == "":
+		return false, errors.New("replist: op has no item ID")
+	case op.Stamp.Replica == "":
+		return false, fmt.Errorf("replist: op %s has no replica", op)
+	case op.Kind == OpPlace && op.Rank.RankString() == "":
+		return false, fmt.Errorf("replist: op %s has no rank", op)
+	case op.Kind != OpPlace && op.Kind != OpRemove:
+		return false, fmt.Errorf("replist: unknown op kind %s", op.Kind)
+	}
+
+	l.clock = max(l.clock, op.Stamp.Counter)
+	if op.Kind == OpPlace && op.Stamp.Replica == l.replica {
+		l.used[op.Rank.String()] = true
+	}
+	cur, ok := l.items[op.ID]
+	if ok && op.Stamp.Compare(cur.stamp) <= 0 {
+		return false, nil
+	}
+
+	if ok && !cur.removed {
+		i, _ := l.search(op.ID, cur)
+		l.order = slices.Delete(l.order, i, i+1)
+	}
+	it := item{rank: op.Rank, stamp: op.Stamp, removed: op.Kind == OpRemove}
+	l.items[op.ID] = it
+	if !it.removed {
+		i, _ := l.search(op.ID, it)
+		l.order = slices.Insert(l.order, i, op.ID)
+	}
+	return true, nil
+}
+
+// search finds the position of id, whose winning op is it, in l.order.
+func (l *List) search(id string, it item) (int, bool) {
+	return slices.BinarySearchFunc(l.order, id, func(e, t string) int {
+		return compare(e, l.items[e], t, it)
+	})
+}
+
+// compare orders two visible items by rank, then by the replica that placed
+// them, then by ID.
+func compare(aID string, a item, bID string, b item) int {
+	return cmp.Or(
+		a.rank.CompareTo(b.rank),
+		strings.Compare(a.stamp.Replica, b.stamp.Replica),
+		strings.Compare(aID, bID),
+	)
+}
@@ -0,0 +1,383 @@
+package replist_test
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"testing"
+
+	"github.com/lupppig/gexorank"
+	"github.com/lupppig/gexorank/replist"
+)
+
+func newList(t *testing.T, replica string) *replist.List {
+	t.Helper()
+	l, err := replist.New(replica)
+	if err != nil {
+		t.Fatalf("New(%q) error: %v", replica, err)
+	}
+	return l
+}
+
+// state returns the list as "id=rank" pairs in order, checking that ranks
+// strictly ascend and that Index agrees with the position.
+func state(t *testing.T, l *replist.List) string {
+	t.Helper()
+	var out []string
+	var last gexorank.LexoRank
+	for id, r := range l.All() {
+		if len(out) > 0 && r.CompareTo(last) <= 0 {
+			t.Fatalf("%s: rank of %s = %q, not after %q", l.Replica(), id, r, last)
+		}
+		if i, ok := l.Index(id); !ok || i != len(out) {
+			t.Fatalf("%s: Index(%s) = %d, %v; want %d", l.Replica(), id, i, ok, len(out))
+		}
+		out = append(out, id+"="+r.String())
+		last = r
+	}
+	return strings.Join(out, " ")
+}
+
+func mustApply(t *testing.T, l *replist.List, ops ...replist.Op) {
+	t.Helper()
+	for _, op := range ops {
+		if _, err := l.Apply(op); err != nil {
+			t.Fatalf("%s: Apply(%s) error: %v", l.Replica(), op, err)
+		}
+	}
+}
+
+// mustOp returns a function that unwraps the result of a local edit.
+func mustOp(t *testing.T) func(replist.Op, error) replist.Op {
+	return func(op replist.Op, err error) replist.Op {
+		t.Helper()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return op
+	}
+}
+
+func TestList_LocalEdits(t *testing.T) {
+	must := mustOp(t)
+	l := newList(t, "a")
+	must(l.Insert("x", 0))
+	must(l.Insert("z", 1))
+	must(l.Insert("y", 1))
+	if got := strings.Join(l.IDs(), ","); got != "x,y,z" {
+		t.Fatalf("IDs() = %s, want x,y,z", got)
+	}
+
+	op, moved, err := l.Move("x", 2)
+	if err != nil || !moved || op.Kind != replist.OpPlace || op.ID != "x" {
+		t.Fatalf("Move(x, 2) = %s, %v, %v", op, moved, err)
+	}
+	if _, moved, _ := l.Move("x", 2); moved {
+		t.Error("Move to own index moved")
+	}
+	must(l.Remove("y"))
+	if got := strings.Join(l.IDs(), ","); got != "z,x" || l.Len() != 2 {
+		t.Errorf("IDs() = %s, want z,x", got)
+	}
+	if _, ok := l.Rank("y"); ok {
+		t.Error("Rank(removed) ok = true")
+	}
+	state(t, l)
+
+	// A removed ID can come back.
+	must(l.Insert("y", 0))
+	if id, _ := l.At(0); id != "y" {
+		t.Errorf("At(0) = %s, want y", id)
+	}
+}
+
+func TestList_NoRankReuse(t *testing.T) {
+	// Another replica may still show x at its old rank when y takes its
+	// place, so y must get a different rank.
+	must := mustOp(t)
+	l := newList(t, "a")
+	must(l.Insert("p", 0))
+	must(l.Insert("q", 1))
+	x := must(l.Insert("x", 1))
+	must(l.Remove("x"))
+	y := must(l.Insert("y", 1))
+	if y.Rank.String() == x.Rank.String() {
+		t.Fatalf("y reused x's rank %q", x.Rank)
+	}
+	if got := strings.Join(l.IDs(), ","); got != "p,y,q" {
+		t.Errorf("IDs() = %s, want p,y,q", got)
+	}
+}
+
+func TestList_Errors(t *testing.T) {
+	must := mustOp(t)
+	if _, err := replist.New(""); err == nil {
+		t.Error("New with empty replica succeeded")
+	}
+	l := newList(t, "a")
+	must(l.Insert("x", 0))
+
+	if _, err := l.Insert("x", 0); !errors.Is(err, gexorank.ErrConflict) {
+		t.Errorf("Insert(existing) error = %v, want ErrConflict", err)
+	}
+	if _, err := l.Insert("y", 5); err == nil {
+		t.Error("Insert out of range succeeded")
+	}
+	if _, _, err := l.Move("nope", 0); !errors.Is(err, gexorank.ErrNotFound) {
+		t.Errorf("Move(missing) error = %v, want ErrNotFound", err)
+	}
+	if _, _, err := l.Move("x", 1); err == nil {
+		t.Error("Move out of range succeeded")
+	}
+	if _, err := l.Remove("nope"); !errors.Is(err, gexorank.ErrNotFound) {
+		t.Errorf("Remove(missing) error = %v, want ErrNotFound", err)
+	}
+
+	bad := []replist.Op{
+		{Kind: replist.OpPlace, Rank: gexorank.Initial(), Stamp: replist.Stamp{Counter: 1, Replica: "b"}},
+		{Kind: replist.OpPlace, ID: "y", Stamp: replist.Stamp{Counter: 1, Replica: "b"}},
+		{Kind: replist.OpPlace, ID: "y", Rank: gexorank.Initial()},
+		{Kind: 7, ID: "y", Stamp: replist.Stamp{Counter: 1, Replica: "b"}},
+	}
+	for _, op := range bad {
+		if _, err := l.Apply(op); err == nil {
+			t.Errorf("Apply(%s) succeeded, want error", op)
+		}
+	}
+}
+
+func TestList_SameGapMoves(t *testing.T) {
+	must := mustOp(t)
+	// Both users see a, b, c, d and drag a different card between a and b.
+	alice, bob := newList(t, "alice"), newList(t, "bob")
+	var seed []replist.Op
+	for i, id := range []string{"a", "b", "c", "d"} {
+		seed = append(seed, must(alice.Insert(id, i)))
+	}
+	mustApply(t, bob, seed...)
+
+	opA, _, err := alice.Move("c", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opB, _, err := bob.Move("d", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opA.Rank.RankString() == opB.Rank.RankString() {
+		t.Fatalf("concurrent moves produced the same rank %q", opA.Rank)
+	}
+	mustApply(t, alice, opB)
+	mustApply(t, bob, opA)
+
+	if sa, sb := state(t, alice), state(t, bob); sa != sb {
+		t.Fatalf("replicas diverged:\n alice %s\n bob   %s", sa, sb)
+	}
+	got := strings.Join(alice.IDs(), ",")
+	if got != "a,c,d,b" && got != "a,d,c,b" {
+		t.Errorf("IDs() = %s, want c and d between a and b", got)
+	}
+}
+
+func TestList_LastWriterWins(t *testing.T) {
+	must := mustOp(t)
+	alice, bob := newList(t, "alice"), newList(t, "bob")
+	var seed []replist.Op
+	for i, id := range []string{"a", "b", "c"} {
+		seed = append(seed, must(alice.Insert(id, i)))
+	}
+	mustApply(t, bob, seed...)
+
+	// Concurrent moves of the same card: equal counters, so the higher
+	// replica ID wins everywhere.
+	opA, _, _ := alice.Move("a", 2)
+	opB, _, _ := bob.Move("a", 1)
+	mustApply(t, alice, opB)
+	mustApply(t, bob, opA)
+	if got := strings.Join(alice.IDs(), ","); got != "b,a,c" || state(t, alice) != state(t, bob) {
+		t.Errorf("alice = %s, bob = %s; want bob's move b,a,c on both", alice.IDs(), bob.IDs())
+	}
+
+	// A move made after seeing a removal brings the item back; a stale op
+	// does not.
+	rm := must(alice.Remove("c"))
+	mustApply(t, bob, rm)
+	back, _ := bob.Insert("c", 0)
+	mustApply(t, alice, back)
+	if changed, _ := alice.Apply(rm); changed {
+		t.Error("re-applying an older remove changed the list")
+	}
+	if got := strings.Join(alice.IDs(), ","); got != "c,b,a" || state(t, alice) != state(t, bob) {
+		t.Errorf("alice = %s, bob = %s; want c,b,a on both", alice.IDs(), bob.IDs())
+	}
+}
+
+func TestList_EqualRankTiebreak(t *testing.T) {
+	// Untagged ranks imported from elsewhere may collide; every replica
+	// orders them by placing replica, then ID.
+	r := gexorank.Initial()
+	ops := []replist.Op{
+		{Kind: replist.OpPlace, ID: "y", Rank: r, Stamp: replist.Stamp{Counter: 1, Replica: "b"}},
+		{Kind: replist.OpPlace, ID: "x", Rank: r, Stamp: replist.Stamp{Counter: 1, Replica: "c"}},
+		{Kind: replist.OpPlace, ID: "w", Rank: r, Stamp: replist.Stamp{Counter: 2, Replica: "b"}},
+	}
+	for _, perm := range [][]int{{0, 1, 2}, {2, 1, 0}, {1, 0, 2}} {
+		l := newList(t, "a")
+		for _, i := range perm {
+			mustApply(t, l, ops[i])
+		}
+		if got := strings.Join(l.IDs(), ","); got != "w,y,x" {
+			t.Errorf("order %v: IDs() = %s, want w,y,x", perm, got)
+		}
+	}
+}
+
+// --- Convergence properties ---
+
+// simulate runs random local edits on replicas that exchange ops over an
+// unreliable network, delivering each op late, out of order and sometimes
+// twice, and returns every op made.
+func simulate(t *testing.T, rng *rand.Rand, replicas []*replist.List, steps int) []replist.Op {
+	t.Helper()
+	var log []replist.Op
+	inbox := make([][]replist.Op, len(replicas))
+	nextID := 0
+	for range steps {
+		r := rng.IntN(len(replicas))
+		l := replicas[r]
+
+		// Deliver some pending ops in random order.
+		rng.Shuffle(len(inbox[r]), func(i, j int) { inbox[r][i], inbox[r][j] = inbox[r][j], inbox[r][i] })
+		n := rng.IntN(len(inbox[r]) + 1)
+		mustApply(t, l, inbox[r][:n]...)
+		inbox[r] = inbox[r][n:]
+
+		var op replist.Op
+		var err error
+		switch k := rng.IntN(10); {
+		case k < 4 || l.Len() < 2:
+			op, err = l.Insert(fmt.Sprintf("i%d", nextID), rng.IntN(l.Len()+1))
+			nextID++
+		case k < 8:
+			id, _ := l.At(rng.IntN(l.Len()))
+			var moved bool
+			if op, moved, err = l.Move(id, rng.IntN(l.Len())); !moved && err == nil {
+				continue
+			}
+		default:
+			id, _ := l.At(rng.IntN(l.Len()))
+			op, err = l.Remove(id)
+		}
+		if err != nil {
+			t.Fatalf("%s: local edit error: %v", l.Replica(), err)
+		}
+		log = append(log, op)
+		for j := range replicas {
+			if j != r {
+				inbox[j] = append(inbox[j], op)
+				if rng.IntN(5) == 0 {
+					inbox[j] = append(inbox[j], op) // duplicate delivery
+				}
+			}
+		}
+	}
+	for j, l := range replicas {
+		mustApply(t, l, inbox[j]...)
+	}
+	return log
+}
+
+func TestList_Convergence(t *testing.T) {
+	for seed := range uint64(20) {
+		rng := rand.New(rand.NewPCG(seed, 42))
+		replicas := []*replist.List{newList(t, "a"), newList(t, "b"), newList(t, "c")}
+		log := simulate(t, rng, replicas, 300)
+
+		want := state(t, replicas[0])
+		for _, l := range replicas[1:] {
+			if got := state(t, l); got != want {
+				t.Fatalf("seed %d: replica %s diverged:\n got  %s\n want %s", seed, l.Replica(), got, want)
+			}
+		}
+
+		// Any permutation of the full log, applied to a fresh replica, gives
+		// the same list.
+		for range 5 {
+			ops := append([]replist.Op(nil), log...)
+			rng.Shuffle(len(ops), func(i, j int) { ops[i], ops[j] = ops[j], ops[i] })
+			l := newList(t, "z")
+			mustApply(t, l, ops...)
+			mustApply(t, l, ops[:len(ops)/2]...) // idempotent
+			if got := state(t, l); got != want {
+				t.Fatalf("seed %d: shuffled log diverged:\n got  %s\n want %s", seed, got, want)
+			}
+		}
+	}
+}
+
+func ExampleList() {
+	alice, _ := replist.New("alice")
+	bob, _ := replist.New("bob")
+
+	for i, card := range []string{"todo", "doing", "done"} {
+		op, _ := alice.Insert(card, i)
+		bob.Apply(op)
+	}
+
+	// Both move a card to the top at the same time.
+	fromAlice, _, _ := alice.Move("done", 0)
+	fromBob, _, _ := bob.Move("doing", 0)
+	alice.Apply(fromBob)
+	bob.Apply(fromAlice)
+
+	fmt.Println(alice.IDs())
+	fmt.Println(bob.IDs())
+	// Output:
+	// [done doing todo]
+	// [done doing todo]
+}
+
+func TestList_GapExhausted(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []gexorank.Option
+		at   func(l *replist.List) int
+	}{
+		{"same gap", nil, func(*replist.List) int { return 1 }},
+		{"same gap short", []gexorank.Option{gexorank.WithMaxLength(16)}, func(*replist.List) int { return 1 }},
+		{"prepend", []gexorank.Option{gexorank.WithDefaultLength(2), gexorank.WithMaxLength(6)}, func(*replist.List) int { return 0 }},
+		{"append", []gexorank.Option{gexorank.WithDefaultLength(2), gexorank.WithMaxLength(6)}, (*replist.List).Len},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, err := replist.New("a", tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			must := mustOp(t)
+			must(l.Insert("first", 0))
+			must(l.Insert("last", 1))
+			for n := 0; ; n++ {
+				if n == 10000 {
+					t.Fatalf("no ErrRankExhausted after %d inserts", n)
+				}
+				id := fmt.Sprint("item-", n)
+				op, err := l.Insert(id, tt.at(l))
+				if errors.Is(err, gexorank.ErrRankExhausted) {
+					if _, ok := l.Rank(id); ok || l.Len() != n+2 {
+						t.Errorf("failed insert changed the list: Len() = %d, want %d", l.Len(), n+2)
+					}
+					break
+				}
+				if err != nil {
+					t.Fatalf("insert %d: %v", n, err)
+				}
+				if op.Rank.Len() > op.Rank.MaxLen() {
+					t.Fatalf("insert %d: rank %q is longer than %d", n, op.Rank, op.Rank.MaxLen())
+				}
+			}
+			state(t, l)
+		})
+	}
+}
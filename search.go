@@ -0,0 +1,85 @@
+package gexorank
+
+import "slices"
+
+// Search searches for target in a sorted slice of ranks and returns the
+// position where target is found, or the position where it would be inserted
+// to keep the slice sorted, and whether it was found. Ranks are compared with
+// [LexoRank.CompareTo].
+func Search(sorted []LexoRank, target LexoRank) (int, bool) {
+	return SearchFunc(sorted, target, identity)
+}
+
+// SearchFunc is like [Search] for a sorted slice of any element type, such
+// as database rows, using key to extract each element's rank.
+func SearchFunc[S ~[]E, E any](sorted S, target LexoRank, key func(E) LexoRank) (int, bool) {
+	return slices.BinarySearchFunc(sorted, target, func(e E, t LexoRank) int {
+		return key(e).CompareTo(t)
+	})
+}
+
+// IndexOf returns the position of target in a sorted slice of ranks, or -1
+// if it is not present.
+func IndexOf(sorted []LexoRank, target LexoRank) int {
+	return IndexOfFunc(sorted, target, identity)
+}
+
+// IndexOfFunc is like [IndexOf] for a sorted slice of any element type.
+func IndexOfFunc[S ~[]E, E any](sorted S, target LexoRank, key func(E) LexoRank) int {
+	if i, found := SearchFunc(sorted, target, key); found {
+		return i
+	}
+	return -1
+}
+
+// Neighbors returns the ranks immediately before and after target's position
+// in a sorted slice, ready to pass to [GenBetween] or [Move]. If target is in
+// the slice, it is skipped: the neighbors are the ranks around it. A nil
+// pointer means target is at the start or end. The pointers refer to
+// elements of sorted.
+func Neighbors(sorted []LexoRank, target LexoRank) (prev, next *LexoRank) {
+	i, found := Search(sorted, target)
+	if i > 0 {
+		prev = &sorted[i-1]
+	}
+	if found {
+		i++
+	}
+	if i < len(sorted) {
+		next = &sorted[i]
+	}
+	return prev, next
+}
+
+// NeighborsFunc is like [Neighbors] for a sorted slice of any element type.
+// The returned ranks are copies of the neighbors' keys.
+func NeighborsFunc[S ~[]E, E any](sorted S, target LexoRank, key func(E) LexoRank) (prev, next *LexoRank) {
+	i, found := SearchFunc(sorted, target, key)
+	if i > 0 {
+		r := key(sorted[i-1])
+		prev = &r
+	}
+	if found {
+		i++
+	}
+	if i < len(sorted) {
+		r := key(sorted[i])
+		next = &r
+	}
+	return prev, next
+}
+
+// IsSorted reports whether ranks are in ascending order, as [Sort] leaves
+// them and the search helpers require. Equal adjacent ranks are allowed.
+func IsSorted(ranks []LexoRank) bool {
+	return IsSortedFunc(ranks, identity)
+}
+
+// IsSortedFunc is like [IsSorted] for a slice of any element type.
+func IsSortedFunc[S ~[]E, E any](s S, key func(E) LexoRank) bool {
+	return slices.IsSortedFunc(s, func(a, b E) int {
+		return key(a).CompareTo(key(b))
+	})
+}
+
+func identity(r LexoRank) LexoRank { return r }
@@ -0,0 +1,136 @@
+package gexorank_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/lupppig/gexorank"
+)
+
+// --- Search Tests ---
+
+func TestSearch(t *testing.T) {
+	sorted := []gexorank.LexoRank{
+		mustParse(t, "0|b"), mustParse(t, "0|d"), mustParse(t, "0|f"), mustParse(t, "1|a"),
+	}
+
+	tests := []struct {
+		target     string
+		index      int
+		found      bool
+		prev, next string
+	}{
+		{"0|a", 0, false, "", "0|b"},
+		{"0|b", 0, true, "", "0|d"},
+		{"0|c", 1, false, "0|b", "0|d"},
+		{"0|d0", 1, true, "0|b", "0|f"}, // equal to 0|d under padded comparison
+		{"0|z", 3, false, "0|f", "1|a"},
+		{"1|a", 3, true, "0|f", ""},
+		{"2|a", 4, false, "1|a", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			target := mustParse(t, tt.target)
+			i, found := gexorank.Search(sorted, target)
+			if i != tt.index || found != tt.found {
+				t.Errorf("Search = %d, %v; want %d, %v", i, found, tt.index, tt.found)
+			}
+
+			wantIdx := -1
+			if tt.found {
+				wantIdx = tt.index
+			}
+			if got := gexorank.IndexOf(sorted, target); got != wantIdx {
+				t.Errorf("IndexOf = %d, want %d", got, wantIdx)
+			}
+
+			prev, next := gexorank.Neighbors(sorted, target)
+			if str(prev) != tt.prev || str(next) != tt.next {
+				t.Errorf("Neighbors = %q, %q; want %q, %q", str(prev), str(next), tt.prev, tt.next)
+			}
+		})
+	}
+
+	if i, found := gexorank.Search(nil, sorted[0]); i != 0 || found {
+		t.Errorf("Search(nil) = %d, %v; want 0, false", i, found)
+	}
+	if prev, next := gexorank.Neighbors(nil, sorted[0]); prev != nil || next != nil {
+		t.Errorf("Neighbors(nil) = %v, %v; want nil, nil", prev, next)
+	}
+}
+
+func str(r *gexorank.LexoRank) string {
+	if r == nil {
+		return ""
+	}
+	return r.String()
+}
+
+func TestSearchFunc(t *testing.T) {
+	type task struct {
+		title string
+		rank  gexorank.LexoRank
+	}
+	key := func(t task) gexorank.LexoRank { return t.rank }
+	tasks := []task{
+		{"a", mustParse(t, "0|b")},
+		{"b", mustParse(t, "0|d")},
+		{"c", mustParse(t, "0|f")},
+	}
+
+	if i, found := gexorank.SearchFunc(tasks, mustParse(t, "0|e"), key); i != 2 || found {
+		t.Errorf("SearchFunc = %d, %v; want 2, false", i, found)
+	}
+	if i := gexorank.IndexOfFunc(tasks, mustParse(t, "0|d"), key); i != 1 {
+		t.Errorf("IndexOfFunc = %d, want 1", i)
+	}
+	if i := gexorank.IndexOfFunc(tasks, mustParse(t, "0|e"), key); i != -1 {
+		t.Errorf("IndexOfFunc(missing) = %d, want -1", i)
+	}
+	prev, next := gexorank.NeighborsFunc(tasks, mustParse(t, "0|d"), key)
+	if str(prev) != "0|b" || str(next) != "0|f" {
+		t.Errorf("NeighborsFunc = %q, %q; want 0|b, 0|f", str(prev), str(next))
+	}
+	if !gexorank.IsSortedFunc(tasks, key) {
+		t.Error("IsSortedFunc = false for sorted tasks")
+	}
+	tasks[0], tasks[2] = tasks[2], tasks[0]
+	if gexorank.IsSortedFunc(tasks, key) {
+		t.Error("IsSortedFunc = true for unsorted tasks")
+	}
+}
+
+func TestIsSorted(t *testing.T) {
+	a, b := mustParse(t, "0|a"), mustParse(t, "1|0")
+	tests := []struct {
+		name  string
+		ranks []gexorank.LexoRank
+		want  bool
+	}{
+		{"empty", nil, true},
+		{"ascending", []gexorank.LexoRank{a, b}, true},
+		{"equal", []gexorank.LexoRank{a, a}, true},
+		{"descending across buckets", []gexorank.LexoRank{b, a}, false},
+	}
+	for _, tt := range tests {
+		if got := gexorank.IsSorted(tt.ranks); got != tt.want {
+			t.Errorf("%s: IsSorted = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func ExampleNeighbors() {
+	var sorted []gexorank.LexoRank
+	for _, s := range []string{"0|a", "0|c", "0|e"} {
+		r, _ := gexorank.Parse(s)
+		sorted = append(sorted, r)
+	}
+
+	// Where does 0|d go, and what rank should a new item there get?
+	target, _ := gexorank.Parse("0|d")
+	i, _ := gexorank.Search(sorted, target)
+	prev, next := gexorank.Neighbors(sorted, target)
+	rank, _ := gexorank.GenBetween(prev, next)
+	fmt.Println(i, prev, next, rank)
+	// Output: 2 0|c 0|e 0|d
+}
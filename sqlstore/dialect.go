@@ -0,0 +1,107 @@
+package sqlstore
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Dialect selects the SQL flavor a [Store] generates.
+type Dialect uint8
+
+const (
+	// Postgres generates SQL for PostgreSQL: $n placeholders, "quoted"
+	// identifiers and FOR UPDATE row locks.
+	Postgres Dialect = iota
+	// MySQL generates SQL for MySQL and MariaDB: ? placeholders, `quoted`
+	// identifiers and FOR UPDATE row locks.
+	MySQL
+	// SQLite generates SQL for SQLite: ? placeholders and "quoted"
+	// identifiers. SQLite has no row locks; writers are serialized by the
+	// database lock instead.
+	SQLite
+)
+
+// String returns the dialect name, e.g. "postgres".
+func (d Dialect) String() string {
+	switch d {
+	case Postgres:
+		return "postgres"
+	case MySQL:
+		return "mysql"
+	case SQLite:
+		return "sqlite"
+	default:
+		return fmt.Sprintf("Dialect(%d)", uint8(d))
+	}
+}
+
+// valid reports whether d is a known dialect.
+func (d Dialect) valid() bool {
+	return d <= SQLite
+}
+
+// quote quotes an identifier, including each part of a schema-qualified name.
+func (d Dialect) quote(ident string) string {
+	q := `"`
+	if d == MySQL {
+		q = "`"
+	}
+	parts := strings.Split(ident, ".")
+	for i, p := range parts {
+		parts[i] = q + p + q
+	}
+	return strings.Join(parts, ".")
+}
+
+// rebind rewrites ? placeholders into the dialect's style.
+func (d Dialect) rebind(query string) string {
+	if d != Postgres {
+		return query
+	}
+	var b strings.Builder
+	n := 0
+	for i := 0; i < len(query); i++ {
+		if query[i] != '?' {
+			b.WriteByte(query[i])
+			continue
+		}
+		n++
+		b.WriteByte('$')
+		b.WriteString(strconv.Itoa(n))
+	}
+	return b.String()
+}
+
+// forUpdate returns the row-locking suffix for SELECT statements.
+func (d Dialect) forUpdate() string {
+	if d == SQLite {
+		return ""
+	}
+	return " FOR UPDATE"
+}
+
+// isUniqueViolation reports whether err is the dialect's unique constraint
+// violation. Drivers are matched by the SQLSTATE they expose or, failing
+// that, by the standard error text, so no driver package is imported.
+func (d Dialect) isUniqueViolation(err error) bool {
+	if err == nil {
+		return false
+	}
+	var state interface{ SQLState() string }
+	if errors.As(err, &state) && state.SQLState() == "23505" {
+		return true
+	}
+	msg := err.Error()
+	switch d {
+	case Postgres:
+		return strings.Contains(msg, "23505") || strings.Contains(msg, "duplicate key value")
+	case MySQL:
+		return strings.Contains(msg, "Error 1062") || strings.Contains(msg, "Duplicate entry")
+	case SQLite:
+		return strings.Contains(msg, "UNIQUE constraint failed")
+	default:
+		return false
+	}
+}
@@ -0,0 +1,287 @@
+package sqlstore_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/lupppig/gexorank/sqlstore"
+)
+
+// fakeDB is a database/sql driver over a single in-memory (id, rank) table
+// with a UNIQUE rank column. It understands exactly the statements Store
+// generates, logs them verbatim, and reports unique violations the way each
+// dialect's driver does.
+type fakeDB struct {
+	dialect sqlstore.Dialect
+
+	mu      sync.Mutex
+	rows    map[string]string // id -> rank
+	log     []string
+	failOn  string // substring of a statement that should fail
+	commits int
+	rolls   int
+}
+
+func newFakeDB(d sqlstore.Dialect, rows map[string]string) (*fakeDB, *sql.DB) {
+	f := &fakeDB{dialect: d, rows: maps.Clone(rows)}
+	if f.rows == nil {
+		f.rows = make(map[string]string)
+	}
+	db := sql.OpenDB(f)
+	db.SetMaxOpenConns(1)
+	return f, db
+}
+
+// queries returns and clears the statement log.
+func (f *fakeDB) queries() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	q := f.log
+	f.log = nil
+	return q
+}
+
+func (f *fakeDB) Connect(context.Context) (driver.Conn, error) { return &fakeConn{db: f}, nil }
+func (f *fakeDB) Driver() driver.Driver                        { return fakeDriver{f} }
+
+type fakeDriver struct{ db *fakeDB }
+
+func (d fakeDriver) Open(string) (driver.Conn, error) { return &fakeConn{db: d.db}, nil }
+
+type fakeConn struct {
+	db       *fakeDB
+	snapshot map[string]string // table before the open transaction
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) {
+	return nil, errors.New("fakedb: prepared statements not supported")
+}
+func (c *fakeConn) Close() error { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) {
+	return c.BeginTx(context.Background(), driver.TxOptions{})
+}
+
+func (c *fakeConn) BeginTx(context.Context, driver.TxOptions) (driver.Tx, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.snapshot = maps.Clone(c.db.rows)
+	return c, nil
+}
+
+func (c *fakeConn) Commit() error {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.snapshot = nil
+	c.db.commits++
+	return nil
+}
+
+func (c *fakeConn) Rollback() error {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.db.rows, c.snapshot = c.snapshot, nil
+	c.db.rolls++
+	return nil
+}
+
+var (
+	placeholderRe = regexp.MustCompile(`\$\d+`)
+	selectRankRe  = regexp.MustCompile(`^SELECT rank FROM tasks WHERE id = \?( FOR UPDATE)?$`)
+	selectRowsRe  = regexp.MustCompile(`^SELECT id, rank FROM tasks(?: WHERE (.+?))? ORDER BY rank( DESC)?(?: LIMIT (\d+))?( FOR UPDATE)?$`)
+	updateRe      = regexp.MustCompile(`^UPDATE tasks SET rank = \? WHERE id = \?( AND rank = \?)?$`)
+	insertRe      = regexp.MustCompile(`^INSERT INTO tasks \(id, rank\) VALUES \(\?, \?\)$`)
+	updateCaseRe  = regexp.MustCompile(`^UPDATE tasks SET rank = CASE id((?: WHEN \? THEN \?)+) END WHERE id IN \(\?(?:, \?)*\)$`)
+)
+
+// normalize strips the dialect's quoting and placeholders so that one set of
+// patterns covers all dialects.
+func (c *fakeConn) normalize(query string) string {
+	q := strings.NewReplacer(`"`, "", "`", "").Replace(query)
+	return placeholderRe.ReplaceAllString(q, "?")
+}
+
+func (c *fakeConn) ExecContext(_ context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	if err := c.record(query); err != nil {
+		return nil, err
+	}
+	q, a := c.normalize(query), values(args)
+
+	if m := updateRe.FindStringSubmatch(q); m != nil {
+		rank, id := a[0], a[1]
+		cur, ok := c.db.rows[id]
+		if !ok || (m[1] != "" && cur != a[2]) {
+			return driver.RowsAffected(0), nil
+		}
+		if err := c.checkUnique(id, rank); err != nil {
+			return nil, err
+		}
+		if cur == rank && c.db.dialect == sqlstore.MySQL {
+			return driver.RowsAffected(0), nil
+		}
+		c.db.rows[id] = rank
+		return driver.RowsAffected(1), nil
+	}
+
+	if insertRe.MatchString(q) {
+		id, rank := a[0], a[1]
+		if _, ok := c.db.rows[id]; ok {
+			return nil, c.duplicate(id)
+		}
+		if err := c.checkUnique(id, rank); err != nil {
+			return nil, err
+		}
+		c.db.rows[id] = rank
+		return driver.RowsAffected(1), nil
+	}
+
+	if m := updateCaseRe.FindStringSubmatch(q); m != nil {
+		// Rows are updated one at a time and the UNIQUE constraint is
+		// checked after each, as MySQL does.
+		n := strings.Count(m[1], "WHEN")
+		cases, in := a[:2*n], a[2*n:]
+		set := make(map[string]string, n)
+		for i := 0; i < len(cases); i += 2 {
+			set[cases[i]] = cases[i+1]
+		}
+		affected := 0
+		for _, id := range in {
+			rank, ok := set[id]
+			if _, exists := c.db.rows[id]; !exists || !ok {
+				continue
+			}
+			if err := c.checkUnique(id, rank); err != nil {
+				return nil, err
+			}
+			c.db.rows[id] = rank
+			affected++
+		}
+		return driver.RowsAffected(affected), nil
+	}
+
+	return nil, fmt.Errorf("fakedb: unsupported statement %q", query)
+}
+
+func (c *fakeConn) QueryContext(_ context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	if err := c.record(query); err != nil {
+		return nil, err
+	}
+	q, a := c.normalize(query), values(args)
+
+	if selectRankRe.MatchString(q) {
+		r := &fakeRows{cols: []string{"rank"}}
+		if rank, ok := c.db.rows[a[0]]; ok {
+			r.data = [][]string{{rank}}
+		}
+		return r, nil
+	}
+
+	if m := selectRowsRe.FindStringSubmatch(q); m != nil {
+		var lo, hi *string
+		if m[1] != "" {
+			for i, cond := range strings.Split(m[1], " AND ") {
+				switch cond {
+				case "rank > ?":
+					lo = &a[i]
+				case "rank < ?":
+					hi = &a[i]
+				default:
+					return nil, fmt.Errorf("fakedb: unsupported condition %q", cond)
+				}
+			}
+		}
+		var data [][]string
+		for _, id := range c.sortedIDs(m[2] != "") {
+			rank := c.db.rows[id]
+			if (lo == nil || rank > *lo) && (hi == nil || rank < *hi) {
+				data = append(data, []string{id, rank})
+			}
+		}
+		if m[3] != "" {
+			n, _ := strconv.Atoi(m[3])
+			data = data[:min(n, len(data))]
+		}
+		return &fakeRows{cols: []string{"id", "rank"}, data: data}, nil
+	}
+
+	return nil, fmt.Errorf("fakedb: unsupported query %q", query)
+}
+
+// record logs query and fails it if it matches failOn. c.db.mu must be held.
+func (c *fakeConn) record(query string) error {
+	c.db.log = append(c.db.log, query)
+	if c.db.failOn != "" && strings.Contains(query, c.db.failOn) {
+		return errors.New("fakedb: injected failure")
+	}
+	return nil
+}
+
+func (c *fakeConn) sortedIDs(desc bool) []string {
+	ids := slices.Collect(maps.Keys(c.db.rows))
+	slices.SortFunc(ids, func(a, b string) int { return strings.Compare(c.db.rows[a], c.db.rows[b]) })
+	if desc {
+		slices.Reverse(ids)
+	}
+	return ids
+}
+
+func (c *fakeConn) checkUnique(id, rank string) error {
+	for other, r := range c.db.rows {
+		if r == rank && other != id {
+			return c.duplicate(rank)
+		}
+	}
+	return nil
+}
+
+// duplicate returns the unique violation error of the dialect's usual driver.
+func (c *fakeConn) duplicate(key string) error {
+	switch c.db.dialect {
+	case sqlstore.Postgres:
+		return errors.New(`pq: duplicate key value violates unique constraint "tasks_rank_key"`)
+	case sqlstore.MySQL:
+		return fmt.Errorf("Error 1062 (23000): Duplicate entry '%s' for key 'tasks.rank'", key)
+	default:
+		return errors.New("UNIQUE constraint failed: tasks.rank")
+	}
+}
+
+func values(args []driver.NamedValue) []string {
+	out := make([]string, len(args))
+	for i, a := range args {
+		out[i] = fmt.Sprint(a.Value)
+	}
+	return out
+}
+
+type fakeRows struct {
+	cols []string
+	data [][]string
+}
+
+func (r *fakeRows) Columns() []string { return r.cols }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.data) == 0 {
+		return io.EOF
+	}
+	for i, v := range r.data[0] {
+		dest[i] = v
+	}
+	r.data = r.data[1:]
+	return nil
+}
@@ -0,0 +1,480 @@
+// Package sqlstore implements [gexorank.RankStore] on top of database/sql,
+// generating dialect-specific SQL for PostgreSQL, MySQL and SQLite.
+//
+// A [Store] works on one table with an ID column and a rank column:
+//
+//	CREATE TABLE tasks (
+//	    id   VARCHAR(64)  PRIMARY KEY,
+//	    rank VARCHAR(256) NOT NULL UNIQUE
+//	);
+//
+// Ranks are compared by the database, so the rank column must use a binary
+// (byte-wise) collation and hold canonical values. The UNIQUE constraint is
+// what turns concurrent duplicate ranks into [gexorank.ErrConflict].
+//
+// Besides the RankStore methods, a Store offers transactional operations that
+// lock the rows they read: [Store.InsertBetween], [Store.Move] and
+// [Store.Rebalance].
+//
+// Upserts are out of scope: a Store only inserts rows for new IDs, and only
+// with the ID and rank columns, reporting an existing ID as
+// [gexorank.ErrConflict]. Every other write is an UPDATE of existing rows,
+// which leaves the table's other columns alone.
+package sqlstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/lupppig/gexorank"
+)
+
+// Config describes the table a [Store] operates on.
+type Config struct {
+	// Dialect selects the generated SQL.
+	Dialect Dialect
+	// Table is the table name, optionally schema-qualified ("app.tasks").
+	Table string
+	// IDColumn is the primary key column. Defaults to "id".
+	IDColumn string
+	// RankColumn is the rank column. Defaults to "rank".
+	RankColumn string
+	// Ranker parses and generates ranks. Defaults to [gexorank.Default].
+	Ranker *gexorank.Ranker
+}
+
+// DefaultBatchSize is the number of rows [Store.Rebalance] writes per
+// statement.
+const DefaultBatchSize = 500
+
+// Store is a [gexorank.RankStore] backed by a database/sql table. It is safe
+// for concurrent use.
+type Store struct {
+	db      *sql.DB
+	dialect Dialect
+	rk      *gexorank.Ranker
+
+	table, id, rank string // quoted identifiers
+}
+
+var _ gexorank.RankStore = (*Store)(nil)
+
+// identRe matches plain and schema-qualified SQL identifiers.
+var identRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// New returns a Store for the table described by cfg. It returns an error if
+// the dialect is unknown or a name is not a plain identifier.
+func New(db *sql.DB, cfg Config) (*Store, error) {
+	if db == nil {
+		return nil, fmt.Errorf("sqlstore: db must not be nil")
+	}
+	if !cfg.Dialect.valid() {
+		return nil, fmt.Errorf("sqlstore: unknown dialect %s", cfg.Dialect)
+	}
+	if cfg.IDColumn == "" {
+		cfg.IDColumn = "id"
+	}
+	if cfg.RankColumn == "" {
+		cfg.RankColumn = "rank"
+	}
+	if cfg.Ranker == nil {
+		cfg.Ranker = gexorank.Default()
+	}
+	for _, name := range []string{cfg.Table, cfg.IDColumn, cfg.RankColumn} {
+		if !identRe.MatchString(name) {
+			return nil, fmt.Errorf("sqlstore: invalid identifier %q", name)
+		}
+	}
+	return &Store{
+		db:      db,
+		dialect: cfg.Dialect,
+		rk:      cfg.Ranker,
+		table:   cfg.Dialect.quote(cfg.Table),
+		id:      cfg.Dialect.quote(cfg.IDColumn),
+		rank:    cfg.Dialect.quote(cfg.RankColumn),
+	}, nil
+}
+
+// querier is the subset of *sql.DB and *sql.Tx the queries need.
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Get returns the rank of item id.
+func (s *Store) Get(ctx context.Context, id string) (gexorank.LexoRank, error) {
+	return s.get(ctx, s.db, id, false)
+}
+
+// Neighbors returns the items immediately before and after item id.
+func (s *Store) Neighbors(ctx context.Context, id string) (prev, next *gexorank.RankedItem, err error) {
+	r, err := s.Get(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if prev, err = s.adjacent(ctx, s.db, r, true); err != nil {
+		return nil, nil, err
+	}
+	if next, err = s.adjacent(ctx, s.db, r, false); err != nil {
+		return nil, nil, err
+	}
+	return prev, next, nil
+}
+
+// CompareAndSet sets the rank of item id to rank if its stored rank is old;
+// a zero old inserts a row with only the ID and rank columns. old is matched
+// exactly, so it should be the value previously read from the store.
+func (s *Store) CompareAndSet(ctx context.Context, id string, old, rank gexorank.LexoRank) error {
+	return s.compareAndSet(ctx, s.db, id, old, rank)
+}
+
+// ListRange returns up to limit items ranked strictly between after and
+// before. A limit of zero or less returns every item in the range.
+func (s *Store) ListRange(ctx context.Context, after, before *gexorank.LexoRank, limit int) ([]gexorank.RankedItem, error) {
+	var where []string
+	var args []any
+	if after != nil {
+		where = append(where, s.rank+" > ?")
+		args = append(args, after.String())
+	}
+	if before != nil {
+		where = append(where, s.rank+" < ?")
+		args = append(args, before.String())
+	}
+
+	query := "SELECT " + s.id + ", " + s.rank + " FROM " + s.table
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY " + s.rank
+	if limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", limit)
+	}
+	return s.list(ctx, s.db, query, args...)
+}
+
+// BulkUpdate sets the rank of every listed item in one transaction, with
+// batched UPDATE ... SET rank = CASE id ... END statements.
+//
+// Databases check the UNIQUE constraint row by row, so an item may not take
+// a rank that another listed item gives up in the same call (a swap, or a
+// respaced window). BulkUpdate therefore writes twice: it first moves every
+// item to a staging value, its new rank with the bucket digit replaced by a
+// letter ("0|abc" becomes "a|abc"), and then to the new rank. Staging values
+// have the same length as the rank and never equal a real rank. It returns
+// [gexorank.ErrNotFound] if an item does not exist and
+// [gexorank.ErrConflict] if a new rank is held by an unlisted row.
+func (s *Store) BulkUpdate(ctx context.Context, items []gexorank.RankedItem) error {
+	seen := make(map[string]bool, len(items))
+	for _, it := range items {
+		if seen[it.ID] {
+			return fmt.Errorf("sqlstore: %q is listed twice", it.ID)
+		}
+		seen[it.ID] = true
+	}
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		for start := 0; start < len(items); start += DefaultBatchSize {
+			batch := items[start:min(start+DefaultBatchSize, len(items))]
+			n, err := s.updateBatch(ctx, tx, batch, staging)
+			if err != nil {
+				return err
+			}
+			if n == int64(len(batch)) {
+				continue
+			}
+			// Some row is missing; staged rows do not parse, so only
+			// check that each one exists.
+			for _, it := range batch {
+				if _, err := s.getRaw(ctx, tx, it.ID, false); err != nil {
+					return err
+				}
+			}
+		}
+		for start := 0; start < len(items); start += DefaultBatchSize {
+			batch := items[start:min(start+DefaultBatchSize, len(items))]
+			if _, err := s.updateBatch(ctx, tx, batch, gexorank.LexoRank.String); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// staging returns the placeholder [Store.BulkUpdate] parks r's row on: r
+// with its bucket digit replaced by a letter, so it is unique among the
+// placeholders of distinct ranks and never a valid rank.
+func staging(r gexorank.LexoRank) string {
+	v := r.String()
+	return string('a'+v[0]-'0') + v[1:]
+}
+
+// InsertBetween inserts item id with a rank between the items prevID and
+// nextID, in one transaction that locks both neighbors. Either ID may be ""
+// for the start or end of the list. The new row only sets the ID and rank
+// columns. It returns [gexorank.ErrNotFound] if a neighbor does not exist and
+// [gexorank.ErrConflict] if a concurrent writer took the rank or the ID.
+func (s *Store) InsertBetween(ctx context.Context, id, prevID, nextID string) (gexorank.LexoRank, error) {
+	var rank gexorank.LexoRank
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		prev, next, err := s.lockPair(ctx, tx, prevID, nextID)
+		if err != nil {
+			return err
+		}
+		if rank, err = s.rk.GenBetween(prev, next); err != nil {
+			return err
+		}
+		return s.compareAndSet(ctx, tx, id, gexorank.LexoRank{}, rank)
+	})
+	if err != nil {
+		return gexorank.LexoRank{}, err
+	}
+	return rank, nil
+}
+
+// Move gives item id a rank between the items prevID and nextID, in one
+// transaction that locks the item and both neighbors. Either neighbor ID may
+// be "" for the start or end of the list. The rank is computed with
+// [gexorank.Ranker.Move]; if the item is already in place, nothing is written
+// and its current rank is returned.
+func (s *Store) Move(ctx context.Context, id, prevID, nextID string) (gexorank.LexoRank, error) {
+	var rank gexorank.LexoRank
+	err := s.inTx(ctx, func(tx *sql.Tx) error {
+		old, err := s.get(ctx, tx, id, true)
+		if err != nil {
+			return err
+		}
+		prev, next, err := s.lockPair(ctx, tx, prevID, nextID)
+		if err != nil {
+			return err
+		}
+		var moved bool
+		if rank, moved, err = s.rk.Move(old, prev, next); err != nil || !moved {
+			return err
+		}
+		return s.compareAndSet(ctx, tx, id, old, rank)
+	})
+	if err != nil {
+		return gexorank.LexoRank{}, err
+	}
+	return rank, nil
+}
+
+// Rebalance rewrites every rank in the table with
+// [gexorank.Ranker.Rebalance] into bucket, in one transaction that locks
+// all rows. Rows are written with UPDATE statements of [DefaultBatchSize]
+// rows each, so other columns are left alone. bucket must differ from the
+// bucket of every row: the new ranks then never collide with old ones still
+// waiting in a later batch, and the UNIQUE constraint holds while the
+// statements run. It returns [gexorank.ErrRankExhausted] if the rows do not
+// fit within the Ranker's max length.
+func (s *Store) Rebalance(ctx context.Context, bucket gexorank.Bucket) error {
+	return s.inTx(ctx, func(tx *sql.Tx) error {
+		query := "SELECT " + s.id + ", " + s.rank + " FROM " + s.table + " ORDER BY " + s.rank + s.dialect.forUpdate()
+		items, err := s.list(ctx, tx, query)
+		if err != nil {
+			return err
+		}
+		for _, it := range items {
+			if it.Rank.Bucket() == bucket {
+				return fmt.Errorf("sqlstore: cannot rebalance into bucket %s: %q is already in it", bucket, it.ID)
+			}
+		}
+
+		ranks := make([]gexorank.LexoRank, len(items))
+		for i, it := range items {
+			ranks[i] = it.Rank
+		}
+		fresh, err := s.rk.Rebalance(ranks, bucket)
+		if err != nil {
+			return err
+		}
+		for i, r := range fresh {
+			items[i].Rank = r
+		}
+
+		for start := 0; start < len(items); start += DefaultBatchSize {
+			if _, err := s.updateBatch(ctx, tx, items[start:min(start+DefaultBatchSize, len(items))], gexorank.LexoRank.String); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// get reads the rank of id, optionally locking the row.
+func (s *Store) get(ctx context.Context, q querier, id string, lock bool) (gexorank.LexoRank, error) {
+	raw, err := s.getRaw(ctx, q, id, lock)
+	if err != nil {
+		return gexorank.LexoRank{}, err
+	}
+	return s.rk.Parse(raw)
+}
+
+// getRaw reads the rank column of id without parsing it.
+func (s *Store) getRaw(ctx context.Context, q querier, id string, lock bool) (string, error) {
+	query := "SELECT " + s.rank + " FROM " + s.table + " WHERE " + s.id + " = ?"
+	if lock {
+		query += s.dialect.forUpdate()
+	}
+	var raw string
+	err := q.QueryRowContext(ctx, s.dialect.rebind(query), id).Scan(&raw)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("%w: %q", gexorank.ErrNotFound, id)
+	}
+	if err != nil {
+		return "", fmt.Errorf("sqlstore: get %q: %w", id, err)
+	}
+	return raw, nil
+}
+
+// adjacent returns the item just before (or after) r, or nil at the end of
+// the list.
+func (s *Store) adjacent(ctx context.Context, q querier, r gexorank.LexoRank, before bool) (*gexorank.RankedItem, error) {
+	op, order := " > ?", ""
+	if before {
+		op, order = " < ?", " DESC"
+	}
+	query := "SELECT " + s.id + ", " + s.rank + " FROM " + s.table +
+		" WHERE " + s.rank + op + " ORDER BY " + s.rank + order + " LIMIT 1"
+	items, err := s.list(ctx, q, query, r.String())
+	if err != nil || len(items) == 0 {
+		return nil, err
+	}
+	return &items[0], nil
+}
+
+// lockPair locks and returns the ranks of prevID and nextID ("" for none).
+func (s *Store) lockPair(ctx context.Context, tx *sql.Tx, prevID, nextID string) (prev, next *gexorank.LexoRank, err error) {
+	for _, p := range []struct {
+		id  string
+		dst **gexorank.LexoRank
+	}{{prevID, &prev}, {nextID, &next}} {
+		if p.id == "" {
+			continue
+		}
+		r, err := s.get(ctx, tx, p.id, true)
+		if err != nil {
+			return nil, nil, err
+		}
+		*p.dst = &r
+	}
+	return prev, next, nil
+}
+
+// compareAndSet implements CompareAndSet on q.
+func (s *Store) compareAndSet(ctx context.Context, q querier, id string, old, rank gexorank.LexoRank) error {
+	if old.RankString() == "" {
+		query := "INSERT INTO " + s.table + " (" + s.id + ", " + s.rank + ") VALUES (?, ?)"
+		if _, err := q.ExecContext(ctx, s.dialect.rebind(query), id, rank.String()); err != nil {
+			return s.classify(err)
+		}
+		return nil
+	}
+
+	query := "UPDATE " + s.table + " SET " + s.rank + " = ? WHERE " + s.id + " = ? AND " + s.rank + " = ?"
+	res, err := q.ExecContext(ctx, s.dialect.rebind(query), rank.String(), id, old.String())
+	if err != nil {
+		return s.classify(err)
+	}
+	if n, err := res.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+
+	// No row matched: find out why.
+	cur, err := s.get(ctx, q, id, false)
+	if err != nil {
+		return err
+	}
+	if cur.String() == rank.String() && old.String() == rank.String() {
+		return nil // unchanged value on MySQL
+	}
+	return fmt.Errorf("%w: %q has rank %s, not %s", gexorank.ErrConflict, id, cur, old)
+}
+
+// updateBatch sets the rank column of existing items to value(rank) with one
+// UPDATE ... SET rank = CASE id ... END statement, and returns the number of
+// rows changed.
+func (s *Store) updateBatch(ctx context.Context, q querier, items []gexorank.RankedItem, value func(gexorank.LexoRank) string) (int64, error) {
+	if len(items) == 0 {
+		return 0, nil
+	}
+	var b, in strings.Builder
+	b.WriteString("UPDATE " + s.table + " SET " + s.rank + " = CASE " + s.id)
+	args := make([]any, 0, 3*len(items))
+	for i, it := range items {
+		b.WriteString(" WHEN ? THEN ?")
+		args = append(args, it.ID, value(it.Rank))
+		if i > 0 {
+			in.WriteString(", ")
+		}
+		in.WriteString("?")
+	}
+	b.WriteString(" END WHERE " + s.id + " IN (" + in.String() + ")")
+	for _, it := range items {
+		args = append(args, it.ID)
+	}
+	res, err := q.ExecContext(ctx, s.dialect.rebind(b.String()), args...)
+	if err != nil {
+		return 0, s.classify(err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("sqlstore: rows affected: %w", err)
+	}
+	return n, nil
+}
+
+// list runs a query returning (id, rank) rows.
+func (s *Store) list(ctx context.Context, q querier, query string, args ...any) ([]gexorank.RankedItem, error) {
+	rows, err := q.QueryContext(ctx, s.dialect.rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlstore: query: %w", err)
+	}
+	defer rows.Close()
+
+	var items []gexorank.RankedItem
+	for rows.Next() {
+		var id, raw string
+		if err := rows.Scan(&id, &raw); err != nil {
+			return nil, fmt.Errorf("sqlstore: scan: %w", err)
+		}
+		r, err := s.rk.Parse(raw)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, gexorank.RankedItem{ID: id, Rank: r})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlstore: query: %w", err)
+	}
+	return items, nil
+}
+
+// classify maps a unique constraint violation to [gexorank.ErrConflict].
+func (s *Store) classify(err error) error {
+	if s.dialect.isUniqueViolation(err) {
+		return fmt.Errorf("%w: %v", gexorank.ErrConflict, err)
+	}
+	return fmt.Errorf("sqlstore: exec: %w", err)
+}
+
+// inTx runs fn in a transaction, committing if it returns nil.
+func (s *Store) inTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("sqlstore: begin: %w", err)
+	}
+	if err := fn(tx); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("sqlstore: commit: %w", err)
+	}
+	return nil
+}
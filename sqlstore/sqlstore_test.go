@@ -0,0 +1,498 @@
+package sqlstore_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lupppig/gexorank"
+	"github.com/lupppig/gexorank/sqlstore"
+)
+
+var dialects = []sqlstore.Dialect{sqlstore.Postgres, sqlstore.MySQL, sqlstore.SQLite}
+
+func newStore(t *testing.T, d sqlstore.Dialect, rows map[string]string) (*fakeDB, *sqlstore.Store) {
+	t.Helper()
+	f, db := newFakeDB(d, rows)
+	t.Cleanup(func() { db.Close() })
+	s, err := sqlstore.New(db, sqlstore.Config{Dialect: d, Table: "tasks"})
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	return f, s
+}
+
+func mustParse(t *testing.T, s string) gexorank.LexoRank {
+	t.Helper()
+	r, err := gexorank.Parse(s)
+	if err != nil {
+		t.Fatalf("Parse(%q) error: %v", s, err)
+	}
+	return r
+}
+
+func ids(items []gexorank.RankedItem) string {
+	var out []string
+	for _, it := range items {
+		out = append(out, it.ID)
+	}
+	return strings.Join(out, ",")
+}
+
+func TestNew(t *testing.T) {
+	_, db := newFakeDB(sqlstore.Postgres, nil)
+	defer db.Close()
+
+	tests := []struct {
+		name string
+		cfg  sqlstore.Config
+	}{
+		{"unknown dialect", sqlstore.Config{Dialect: 9, Table: "tasks"}},
+		{"empty table", sqlstore.Config{}},
+		{"injected table", sqlstore.Config{Table: "tasks; DROP TABLE tasks"}},
+		{"quoted column", sqlstore.Config{Table: "tasks", RankColumn: `"rank"`}},
+		{"three-part name", sqlstore.Config{Table: "db.app.tasks"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := sqlstore.New(db, tt.cfg); err == nil {
+				t.Error("New succeeded, want error")
+			}
+		})
+	}
+	if _, err := sqlstore.New(nil, sqlstore.Config{Table: "tasks"}); err == nil {
+		t.Error("New(nil) succeeded, want error")
+	}
+	if _, err := sqlstore.New(db, sqlstore.Config{Table: "app.tasks"}); err != nil {
+		t.Errorf("New(app.tasks) error: %v", err)
+	}
+}
+
+func TestDialect_String(t *testing.T) {
+	for d, want := range map[sqlstore.Dialect]string{
+		sqlstore.Postgres: "postgres",
+		sqlstore.MySQL:    "mysql",
+		sqlstore.SQLite:   "sqlite",
+		7:                 "Dialect(7)",
+	} {
+		if got := d.String(); got != want {
+			t.Errorf("Dialect(%d).String() = %q, want %q", uint8(d), got, want)
+		}
+	}
+}
+
+func TestStore_SQL(t *testing.T) {
+	ctx := context.Background()
+	tests := []struct {
+		dialect sqlstore.Dialect
+		move    []string
+		rebal   string
+	}{
+		{
+			sqlstore.Postgres,
+			[]string{
+				`SELECT "rank" FROM "tasks" WHERE "id" = $1 FOR UPDATE`,
+				`SELECT "rank" FROM "tasks" WHERE "id" = $1 FOR UPDATE`,
+				`SELECT "rank" FROM "tasks" WHERE "id" = $1 FOR UPDATE`,
+				`UPDATE "tasks" SET "rank" = $1 WHERE "id" = $2 AND "rank" = $3`,
+			},
+			`UPDATE "tasks" SET "rank" = CASE "id" WHEN $1 THEN $2 WHEN $3 THEN $4 WHEN $5 THEN $6 END WHERE "id" IN ($7, $8, $9)`,
+		},
+		{
+			sqlstore.MySQL,
+			[]string{
+				"SELECT `rank` FROM `tasks` WHERE `id` = ? FOR UPDATE",
+				"SELECT `rank` FROM `tasks` WHERE `id` = ? FOR UPDATE",
+				"SELECT `rank` FROM `tasks` WHERE `id` = ? FOR UPDATE",
+				"UPDATE `tasks` SET `rank` = ? WHERE `id` = ? AND `rank` = ?",
+			},
+			"UPDATE `tasks` SET `rank` = CASE `id` WHEN ? THEN ? WHEN ? THEN ? WHEN ? THEN ? END WHERE `id` IN (?, ?, ?)",
+		},
+		{
+			sqlstore.SQLite,
+			[]string{
+				`SELECT "rank" FROM "tasks" WHERE "id" = ?`,
+				`SELECT "rank" FROM "tasks" WHERE "id" = ?`,
+				`SELECT "rank" FROM "tasks" WHERE "id" = ?`,
+				`UPDATE "tasks" SET "rank" = ? WHERE "id" = ? AND "rank" = ?`,
+			},
+			`UPDATE "tasks" SET "rank" = CASE "id" WHEN ? THEN ? WHEN ? THEN ? WHEN ? THEN ? END WHERE "id" IN (?, ?, ?)`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.dialect.String(), func(t *testing.T) {
+			f, s := newStore(t, tt.dialect, map[string]string{"a": "0|a", "b": "0|c", "c": "0|e"})
+
+			if _, err := s.Move(ctx, "c", "a", "b"); err != nil {
+				t.Fatalf("Move error: %v", err)
+			}
+			if got := f.queries(); !slices.Equal(got, tt.move) {
+				t.Errorf("Move SQL:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(tt.move, "\n"))
+			}
+
+			if err := s.Rebalance(ctx, gexorank.Bucket1); err != nil {
+				t.Fatalf("Rebalance error: %v", err)
+			}
+			got := f.queries()
+			if len(got) != 2 || got[1] != tt.rebal {
+				t.Errorf("Rebalance SQL:\n%s\nwant update:\n%s", strings.Join(got, "\n"), tt.rebal)
+			}
+			if lock := strings.HasSuffix(got[0], " FOR UPDATE"); lock != (tt.dialect != sqlstore.SQLite) {
+				t.Errorf("Rebalance SELECT = %q, FOR UPDATE present = %v", got[0], lock)
+			}
+		})
+	}
+}
+
+func TestStore_GetAndNeighbors(t *testing.T) {
+	ctx := context.Background()
+	for _, d := range dialects {
+		t.Run(d.String(), func(t *testing.T) {
+			_, s := newStore(t, d, map[string]string{"a": "0|c", "b": "0|a", "c": "0|b"})
+
+			r, err := s.Get(ctx, "a")
+			if err != nil || r.String() != "0|c" {
+				t.Errorf("Get(a) = %q, %v; want 0|c", r, err)
+			}
+			if _, err := s.Get(ctx, "zz"); !errors.Is(err, gexorank.ErrNotFound) {
+				t.Errorf("Get(missing) error = %v, want ErrNotFound", err)
+			}
+
+			tests := []struct{ id, prev, next string }{
+				{"b", "", "c"},
+				{"c", "b", "a"},
+				{"a", "c", ""},
+			}
+			for _, tt := range tests {
+				prev, next, err := s.Neighbors(ctx, tt.id)
+				if err != nil {
+					t.Fatalf("Neighbors(%s) error: %v", tt.id, err)
+				}
+				var gotPrev, gotNext string
+				if prev != nil {
+					gotPrev = prev.ID
+				}
+				if next != nil {
+					gotNext = next.ID
+				}
+				if gotPrev != tt.prev || gotNext != tt.next {
+					t.Errorf("Neighbors(%s) = %q, %q; want %q, %q", tt.id, gotPrev, gotNext, tt.prev, tt.next)
+				}
+			}
+			if _, _, err := s.Neighbors(ctx, "zz"); !errors.Is(err, gexorank.ErrNotFound) {
+				t.Errorf("Neighbors(missing) error = %v, want ErrNotFound", err)
+			}
+		})
+	}
+}
+
+func TestStore_CompareAndSet(t *testing.T) {
+	ctx := context.Background()
+	for _, d := range dialects {
+		t.Run(d.String(), func(t *testing.T) {
+			_, s := newStore(t, d, map[string]string{"a": "0|a", "b": "0|b"})
+			a, b, c := mustParse(t, "0|a"), mustParse(t, "0|b"), mustParse(t, "0|c")
+
+			if err := s.CompareAndSet(ctx, "a", b, c); !errors.Is(err, gexorank.ErrConflict) {
+				t.Errorf("stale old: error = %v, want ErrConflict", err)
+			}
+			if err := s.CompareAndSet(ctx, "a", a, b); !errors.Is(err, gexorank.ErrConflict) {
+				t.Errorf("taken rank: error = %v, want ErrConflict", err)
+			}
+			if err := s.CompareAndSet(ctx, "b", gexorank.LexoRank{}, c); !errors.Is(err, gexorank.ErrConflict) {
+				t.Errorf("insert existing: error = %v, want ErrConflict", err)
+			}
+			if err := s.CompareAndSet(ctx, "zz", a, c); !errors.Is(err, gexorank.ErrNotFound) {
+				t.Errorf("missing item: error = %v, want ErrNotFound", err)
+			}
+			if err := s.CompareAndSet(ctx, "a", a, a); err != nil {
+				t.Errorf("unchanged rank: error = %v", err)
+			}
+			if err := s.CompareAndSet(ctx, "a", a, c); err != nil {
+				t.Fatalf("CompareAndSet error: %v", err)
+			}
+			if err := s.CompareAndSet(ctx, "d", gexorank.LexoRank{}, a); err != nil {
+				t.Fatalf("insert error: %v", err)
+			}
+			all, _ := s.ListRange(ctx, nil, nil, 0)
+			if ids(all) != "d,b,a" {
+				t.Errorf("order = %q, want d,b,a", ids(all))
+			}
+		})
+	}
+}
+
+func TestStore_ListRange(t *testing.T) {
+	ctx := context.Background()
+	f, s := newStore(t, sqlstore.Postgres, map[string]string{
+		"a": "0|a", "b": "0|b", "c": "0|c", "d": "0|d", "e": "1|a",
+	})
+	b, d := mustParse(t, "0|b"), mustParse(t, "0|d")
+
+	tests := []struct {
+		name          string
+		after, before *gexorank.LexoRank
+		limit         int
+		want, sql     string
+	}{
+		{"all", nil, nil, 0, "a,b,c,d,e",
+			`SELECT "id", "rank" FROM "tasks" ORDER BY "rank"`},
+		{"limited", nil, nil, 2, "a,b",
+			`SELECT "id", "rank" FROM "tasks" ORDER BY "rank" LIMIT 2`},
+		{"exclusive bounds", &b, &d, 0, "c",
+			`SELECT "id", "rank" FROM "tasks" WHERE "rank" > $1 AND "rank" < $2 ORDER BY "rank"`},
+		{"open before", nil, &b, 0, "a",
+			`SELECT "id", "rank" FROM "tasks" WHERE "rank" < $1 ORDER BY "rank"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.ListRange(ctx, tt.after, tt.before, tt.limit)
+			if err != nil {
+				t.Fatalf("ListRange error: %v", err)
+			}
+			if ids(got) != tt.want {
+				t.Errorf("ListRange = %q, want %q", ids(got), tt.want)
+			}
+			if q := f.queries(); len(q) != 1 || q[0] != tt.sql {
+				t.Errorf("SQL = %q, want %q", q, tt.sql)
+			}
+		})
+	}
+}
+
+func TestStore_BulkUpdate(t *testing.T) {
+	ctx := context.Background()
+	f, s := newStore(t, sqlstore.MySQL, map[string]string{"a": "0|a", "b": "0|b", "c": "0|c"})
+
+	err := s.BulkUpdate(ctx, []gexorank.RankedItem{
+		{ID: "a", Rank: mustParse(t, "0|x")},
+		{ID: "b", Rank: mustParse(t, "0|b")}, // unchanged: zero rows on MySQL
+		{ID: "c", Rank: mustParse(t, "0|y")},
+	})
+	if err != nil {
+		t.Fatalf("BulkUpdate error: %v", err)
+	}
+	all, _ := s.ListRange(ctx, nil, nil, 0)
+	if ids(all) != "b,a,c" {
+		t.Errorf("order = %q, want b,a,c", ids(all))
+	}
+
+	err = s.BulkUpdate(ctx, []gexorank.RankedItem{
+		{ID: "a", Rank: mustParse(t, "0|z")},
+		{ID: "zz", Rank: mustParse(t, "0|q")},
+	})
+	if !errors.Is(err, gexorank.ErrNotFound) {
+		t.Errorf("missing item: error = %v, want ErrNotFound", err)
+	}
+	err = s.BulkUpdate(ctx, []gexorank.RankedItem{
+		{ID: "a", Rank: mustParse(t, "0|z")},
+		{ID: "b", Rank: mustParse(t, "0|y")},
+	})
+	if !errors.Is(err, gexorank.ErrConflict) {
+		t.Errorf("duplicate rank: error = %v, want ErrConflict", err)
+	}
+	err = s.BulkUpdate(ctx, []gexorank.RankedItem{
+		{ID: "a", Rank: mustParse(t, "0|z")},
+		{ID: "a", Rank: mustParse(t, "0|w")},
+	})
+	if err == nil {
+		t.Error("item listed twice: expected error, got nil")
+	}
+	if got, _ := s.Get(ctx, "a"); got.String() != "0|x" {
+		t.Errorf("failed BulkUpdate changed a to %q", got)
+	}
+	if f.rolls != 2 {
+		t.Errorf("rollbacks = %d, want 2", f.rolls)
+	}
+}
+
+func TestStore_BulkUpdateSwap(t *testing.T) {
+	ctx := context.Background()
+	for _, d := range dialects {
+		t.Run(d.String(), func(t *testing.T) {
+			f, s := newStore(t, d, map[string]string{"a": "0|a", "b": "0|b", "c": "0|c", "d": "1|a"})
+
+			// a and b trade ranks, and c takes the rank d gives up.
+			err := s.BulkUpdate(ctx, []gexorank.RankedItem{
+				{ID: "a", Rank: mustParse(t, "0|b")},
+				{ID: "b", Rank: mustParse(t, "0|a")},
+				{ID: "c", Rank: mustParse(t, "1|a")},
+				{ID: "d", Rank: mustParse(t, "1|b")},
+			})
+			if err != nil {
+				t.Fatalf("BulkUpdate error: %v", err)
+			}
+			if q := f.queries(); len(q) != 2 {
+				t.Errorf("statements = %q, want a staging and a final UPDATE", q)
+			}
+			all, _ := s.ListRange(ctx, nil, nil, 0)
+			var got []string
+			for _, it := range all {
+				got = append(got, it.ID+"="+it.Rank.String())
+			}
+			if want := "b=0|a a=0|b c=1|a d=1|b"; strings.Join(got, " ") != want {
+				t.Errorf("rows = %s, want %s", strings.Join(got, " "), want)
+			}
+		})
+	}
+}
+
+func TestStore_InsertBetween(t *testing.T) {
+	ctx := context.Background()
+	for _, d := range dialects {
+		t.Run(d.String(), func(t *testing.T) {
+			f, s := newStore(t, d, map[string]string{"a": "0|a", "c": "0|c"})
+
+			r, err := s.InsertBetween(ctx, "b", "a", "c")
+			if err != nil {
+				t.Fatalf("InsertBetween error: %v", err)
+			}
+			if r.String() != "0|b" {
+				t.Errorf("InsertBetween = %q, want 0|b", r)
+			}
+			for _, tc := range []struct{ id, prev, next string }{
+				{"first", "", "a"},
+				{"last", "c", ""},
+			} {
+				if _, err := s.InsertBetween(ctx, tc.id, tc.prev, tc.next); err != nil {
+					t.Fatalf("InsertBetween(%s) error: %v", tc.id, err)
+				}
+			}
+			all, _ := s.ListRange(ctx, nil, nil, 0)
+			if ids(all) != "first,a,b,c,last" {
+				t.Errorf("order = %q, want first,a,b,c,last", ids(all))
+			}
+			if _, err := s.InsertBetween(ctx, "x", "a", "zz"); !errors.Is(err, gexorank.ErrNotFound) {
+				t.Errorf("missing neighbor: error = %v, want ErrNotFound", err)
+			}
+			if _, err := s.InsertBetween(ctx, "a", "b", "c"); !errors.Is(err, gexorank.ErrConflict) {
+				t.Errorf("existing ID: error = %v, want ErrConflict", err)
+			}
+			if f.commits != 3 || f.rolls != 2 {
+				t.Errorf("commits, rollbacks = %d, %d; want 3, 2", f.commits, f.rolls)
+			}
+		})
+	}
+}
+
+func TestStore_InsertBetweenRace(t *testing.T) {
+	ctx := context.Background()
+	f, s := newStore(t, sqlstore.Postgres, map[string]string{"a": "0|a", "c": "0|c"})
+
+	// Another writer takes the midpoint first: the UNIQUE constraint fails
+	// the insert and the transaction is rolled back.
+	f.rows["other"] = "0|b"
+	_, err := s.InsertBetween(ctx, "b", "a", "c")
+	if !errors.Is(err, gexorank.ErrConflict) {
+		t.Fatalf("error = %v, want ErrConflict", err)
+	}
+	if _, err := s.Get(ctx, "b"); !errors.Is(err, gexorank.ErrNotFound) {
+		t.Errorf("Get(b) error = %v, want ErrNotFound", err)
+	}
+}
+
+func TestStore_Move(t *testing.T) {
+	ctx := context.Background()
+	f, s := newStore(t, sqlstore.SQLite, map[string]string{"a": "0|a", "b": "0|b", "c": "0|c"})
+
+	r, err := s.Move(ctx, "a", "c", "")
+	if err != nil {
+		t.Fatalf("Move error: %v", err)
+	}
+	if got, _ := s.Get(ctx, "a"); got.String() != r.String() {
+		t.Errorf("Get(a) = %q, want %q", got, r)
+	}
+	all, _ := s.ListRange(ctx, nil, nil, 0)
+	if ids(all) != "b,c,a" {
+		t.Errorf("order = %q, want b,c,a", ids(all))
+	}
+
+	f.queries()
+	if got, err := s.Move(ctx, "a", "b", ""); err != nil || got.String() != r.String() {
+		t.Errorf("Move in place = %q, %v; want %q", got, err, r)
+	}
+	for _, q := range f.queries() {
+		if strings.HasPrefix(q, "UPDATE") {
+			t.Errorf("Move in place wrote %q", q)
+		}
+	}
+
+	if _, err := s.Move(ctx, "zz", "a", ""); !errors.Is(err, gexorank.ErrNotFound) {
+		t.Errorf("missing item: error = %v, want ErrNotFound", err)
+	}
+	if _, err := s.Move(ctx, "a", "zz", ""); !errors.Is(err, gexorank.ErrNotFound) {
+		t.Errorf("missing neighbor: error = %v, want ErrNotFound", err)
+	}
+	if got, _ := s.Get(ctx, "a"); got.String() != r.String() {
+		t.Errorf("failed Move changed a to %q", got)
+	}
+	if f.rolls != 2 {
+		t.Errorf("rollbacks = %d, want 2", f.rolls)
+	}
+}
+
+func TestStore_Rebalance(t *testing.T) {
+	ctx := context.Background()
+	rows := make(map[string]string)
+	for i := range sqlstore.DefaultBatchSize + 10 {
+		rows[fmt.Sprintf("%04d", i)] = fmt.Sprintf("0|%04d", i)
+	}
+	f, s := newStore(t, sqlstore.Postgres, rows)
+
+	if err := s.Rebalance(ctx, gexorank.Bucket1); err != nil {
+		t.Fatalf("Rebalance error: %v", err)
+	}
+	if q := f.queries(); len(q) != 3 {
+		t.Errorf("statements = %d, want 1 SELECT and 2 UPDATEs", len(q))
+	}
+	all, _ := s.ListRange(ctx, nil, nil, 0)
+	if len(all) != len(rows) {
+		t.Fatalf("rows = %d, want %d", len(all), len(rows))
+	}
+	for i, it := range all {
+		if it.ID != fmt.Sprintf("%04d", i) || it.Rank.Bucket() != gexorank.Bucket1 {
+			t.Fatalf("all[%d] = %s %q, want %04d in bucket 1", i, it.ID, it.Rank, i)
+		}
+	}
+}
+
+func TestStore_RebalanceSameBucket(t *testing.T) {
+	ctx := context.Background()
+	f, s := newStore(t, sqlstore.MySQL, map[string]string{"a": "1|a", "b": "0|b"})
+
+	// A new rank could equal an old one still waiting in a later batch.
+	if err := s.Rebalance(ctx, gexorank.Bucket1); err == nil {
+		t.Fatal("Rebalance into a bucket in use succeeded, want error")
+	}
+	if got := f.queries(); len(got) != 1 {
+		t.Errorf("statements = %q, want only the SELECT", got)
+	}
+	if got, _ := s.Get(ctx, "a"); got.String() != "1|a" {
+		t.Errorf("Get(a) = %q, want 1|a", got)
+	}
+}
+
+func TestStore_RollbackOnError(t *testing.T) {
+	ctx := context.Background()
+	rows := make(map[string]string)
+	for i := range sqlstore.DefaultBatchSize + 10 {
+		rows[fmt.Sprintf("%04d", i)] = fmt.Sprintf("0|%04d", i)
+	}
+	f, s := newStore(t, sqlstore.SQLite, rows)
+
+	// The second batch fails after the first has been written.
+	f.failOn = "IN (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)"
+	if err := s.Rebalance(ctx, gexorank.Bucket1); err == nil {
+		t.Fatal("Rebalance succeeded, want error")
+	}
+	if f.commits != 0 || f.rolls != 1 {
+		t.Errorf("commits, rollbacks = %d, %d; want 0, 1", f.commits, f.rolls)
+	}
+	f.failOn = ""
+	if got, _ := s.Get(ctx, "0000"); got.String() != "0|0000" {
+		t.Errorf("Get(0000) = %q after rollback, want 0|0000", got)
+	}
+}
@@ -0,0 +1,47 @@
+package gexorank
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotFound is returned by a [RankStore] when an item does not exist.
+var ErrNotFound = errors.New("gexorank: item not found")
+
+// ErrConflict is returned by a [RankStore] when a write loses a race: a
+// compare-and-set found a different current rank, or the new rank is already
+// taken by another item.
+var ErrConflict = errors.New("gexorank: rank conflict")
+
+// RankStore is the storage the higher-level operations (moving items,
+// rebalancing, migrating buckets) are built on. Items are identified by ID
+// and ordered by [LexoRank.CompareTo], which is the same as byte-wise order
+// of the rank strings for canonical values. A store must keep ranks unique,
+// like a UNIQUE constraint on the rank column.
+//
+// Implementations must be safe for concurrent use. [MemoryStore] is the
+// reference implementation.
+type RankStore interface {
+	// Get returns the rank of item id, or [ErrNotFound].
+	Get(ctx context.Context, id string) (LexoRank, error)
+
+	// Neighbors returns the items immediately before and after item id in
+	// rank order. Either is nil at the ends of the list. It returns
+	// [ErrNotFound] if id does not exist.
+	Neighbors(ctx context.Context, id string) (prev, next *RankedItem, err error)
+
+	// CompareAndSet sets the rank of item id to rank if its current rank
+	// equals old. A zero old inserts id, which must not exist yet. It returns
+	// [ErrConflict] if the current rank differs or rank is taken by another
+	// item, and [ErrNotFound] if id does not exist (for a non-zero old).
+	CompareAndSet(ctx context.Context, id string, old, rank LexoRank) error
+
+	// ListRange returns up to limit items ranked strictly between after and
+	// before, in ascending order. Either bound may be nil for an open end.
+	ListRange(ctx context.Context, after, before *LexoRank, limit int) ([]RankedItem, error)
+
+	// BulkUpdate sets the rank of every listed item atomically: either all
+	// updates apply or none do. It returns [ErrNotFound] if an item does not
+	// exist and [ErrConflict] if the result would contain duplicate ranks.
+	BulkUpdate(ctx context.Context, items []RankedItem) error
+}
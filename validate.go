@@ -0,0 +1,247 @@
+package gexorank
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Severity ranks how serious a [ValidationIssue] is.
+type Severity uint8
+
+const (
+	// SeverityInfo marks a harmless irregularity, such as a non-canonical
+	// value.
+	SeverityInfo Severity = iota
+	// SeverityWarning marks a rank that works today but will cause failures
+	// later, such as one close to the max length.
+	SeverityWarning
+	// SeverityError marks a broken ordering: items that cannot be told apart
+	// or are out of order.
+	SeverityError
+)
+
+// String returns the severity name, e.g. "warning".
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	default:
+		return fmt.Sprintf("Severity(%d)", uint8(s))
+	}
+}
+
+// IssueKind identifies the problem a [ValidationIssue] reports.
+type IssueKind uint8
+
+const (
+	// IssueUnsorted: the rank sorts before the one preceding it.
+	IssueUnsorted IssueKind = iota
+	// IssueDuplicate: the rank string equals an earlier rank.
+	IssueDuplicate
+	// IssueEquivalent: the rank differs from an earlier rank only by
+	// trailing minimum characters ("0|a" and "0|a00"), so [LexoRank.CompareTo]
+	// treats them as equal and no rank can be generated between them.
+	IssueEquivalent
+	// IssueMixedBucket: the rank is not in the bucket of the first rank.
+	IssueMixedBucket
+	// IssueOutOfRange: the value is at [Min], leaving no room before it, or
+	// is all maximum characters at the max length, leaving no room after it.
+	// Values above [Max] are fine: [LexoRank.GenNext] widens past it.
+	IssueOutOfRange
+	// IssueTooLong: the value is longer than the max length.
+	IssueTooLong
+	// IssueNearMaxLength: the rank needs a rebalance (see
+	// [LexoRank.NeedsRebalance] and [DefaultRebalanceThreshold]).
+	IssueNearMaxLength
+	// IssueNonCanonical: the value ends in the minimum character, which adds
+	// length without changing its position.
+	IssueNonCanonical
+)
+
+var issueKindNames = [...]string{
+	IssueUnsorted:      "unsorted",
+	IssueDuplicate:     "duplicate",
+	IssueEquivalent:    "equivalent",
+	IssueMixedBucket:   "mixed-bucket",
+	IssueOutOfRange:    "out-of-range",
+	IssueTooLong:       "too-long",
+	IssueNearMaxLength: "near-max-length",
+	IssueNonCanonical:  "non-canonical",
+}
+
+// String returns the kind name, e.g. "duplicate".
+func (k IssueKind) String() string {
+	if int(k) < len(issueKindNames) {
+		return issueKindNames[k]
+	}
+	return fmt.Sprintf("IssueKind(%d)", uint8(k))
+}
+
+// severity returns the severity of every issue of kind k.
+func (k IssueKind) severity() Severity {
+	switch k {
+	case IssueUnsorted, IssueDuplicate, IssueEquivalent, IssueTooLong:
+		return SeverityError
+	case IssueNonCanonical:
+		return SeverityInfo
+	default:
+		return SeverityWarning
+	}
+}
+
+// ValidationIssue is one problem found by [Validate].
+type ValidationIssue struct {
+	Kind     IssueKind
+	Severity Severity
+	// Index is the position of the offending rank.
+	Index int
+	// Other is the position of the rank it conflicts with, or -1.
+	Other int
+	Rank  LexoRank
+	// Message describes the problem.
+	Message string
+}
+
+// String formats the issue as "error [duplicate] index 3: ...".
+func (i ValidationIssue) String() string {
+	return fmt.Sprintf("%s [%s] index %d: %s", i.Severity, i.Kind, i.Index, i.Message)
+}
+
+// ValidationReport lists every problem found by [Validate], ordered by
+// index and then by decreasing severity.
+type ValidationReport struct {
+	// Count is the number of ranks validated.
+	Count  int
+	Issues []ValidationIssue
+}
+
+// OK reports whether no issue is an error.
+func (r ValidationReport) OK() bool {
+	return r.Max() < SeverityError
+}
+
+// Max returns the highest severity in the report, or SeverityInfo if it has
+// no issues.
+func (r ValidationReport) Max() Severity {
+	var m Severity
+	for _, i := range r.Issues {
+		m = max(m, i.Severity)
+	}
+	return m
+}
+
+// Filter returns the issues of at least severity atLeast.
+func (r ValidationReport) Filter(atLeast Severity) []ValidationIssue {
+	var out []ValidationIssue
+	for _, i := range r.Issues {
+		if i.Severity >= atLeast {
+			out = append(out, i)
+		}
+	}
+	return out
+}
+
+// String formats the report one issue per line, after a summary line.
+func (r ValidationReport) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d ranks, %d issues", r.Count, len(r.Issues))
+	for _, i := range r.Issues {
+		b.WriteString("\n")
+		b.WriteString(i.String())
+	}
+	return b.String()
+}
+
+// Validate audits a persisted ordering, given as ranks in the order the
+// items are listed (typically by the rank column), and reports every problem
+// with its index and severity: ranks out of order, duplicates, ranks equal
+// under padded comparison, ranks outside the first rank's bucket, values at
+// either end of the ranking space, values that are too long or need a
+// rebalance, and non-canonical values.
+//
+// The limits of the [Ranker] that produced the first rank are used.
+func Validate(ranks []LexoRank) ValidationReport {
+	if len(ranks) == 0 {
+		return ValidationReport{}
+	}
+	return ranks[0].rk().Validate(ranks)
+}
+
+// Validate audits ranks against this Ranker's limits. See the package-level
+// [Validate].
+func (rk *Ranker) Validate(ranks []LexoRank) ValidationReport {
+	report := ValidationReport{Count: len(ranks)}
+	add := func(kind IssueKind, i, other int, format string, args ...any) {
+		report.Issues = append(report.Issues, ValidationIssue{
+			Kind:     kind,
+			Severity: kind.severity(),
+			Index:    i,
+			Other:    other,
+			Rank:     ranks[i],
+			Message:  fmt.Sprintf(format, args...),
+		})
+	}
+
+	// Nothing sorts before Min, and nothing sorts after Max extended to the
+	// max length; everything in between leaves room on both sides.
+	minValue, maxValue := rk.Min().value, rk.alpha.MaxValue(rk.maxLength)
+	for i, r := range ranks {
+		if i > 0 && r.CompareTo(ranks[i-1]) < 0 {
+			add(IssueUnsorted, i, i-1, "%s sorts before %s at index %d", r, ranks[i-1], i-1)
+		}
+		if r.bucket != ranks[0].bucket {
+			add(IssueMixedBucket, i, 0, "%s is not in bucket %s", r, ranks[0].bucket)
+		}
+		switch {
+		case r.value.CompareTo(minValue) <= 0:
+			add(IssueOutOfRange, i, -1, "%s is at the minimum value; nothing can sort before it", r)
+		case r.value.CompareTo(maxValue) >= 0:
+			add(IssueOutOfRange, i, -1, "%s is at the maximum value; nothing can sort after it", r)
+		}
+		switch {
+		case r.Len() > rk.maxLength:
+			add(IssueTooLong, i, -1, "%s is %d characters, over the max length %d", r, r.Len(), rk.maxLength)
+		case float64(r.Len()) >= DefaultRebalanceThreshold*float64(rk.maxLength):
+			add(IssueNearMaxLength, i, -1, "%s is %d of %d characters", r, r.Len(), rk.maxLength)
+		}
+		if !r.IsCanonical() {
+			add(IssueNonCanonical, i, -1, "%s has trailing %q padding", r, r.value.set().Min())
+		}
+	}
+
+	// Sort positions by rank to find equal ranks anywhere in the list.
+	order := make([]int, len(ranks))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return ranks[a].CompareTo(ranks[b])
+	})
+	for start := 0; start < len(order); {
+		end := start + 1
+		for end < len(order) && ranks[order[end]].CompareTo(ranks[order[start]]) == 0 {
+			end++
+		}
+		// The stable sort puts the lowest index of each run first.
+		first := order[start]
+		for _, i := range order[start+1 : end] {
+			if ranks[first].String() == ranks[i].String() {
+				add(IssueDuplicate, i, first, "%s duplicates index %d", ranks[i], first)
+			} else {
+				add(IssueEquivalent, i, first, "%s is equal to %s at index %d under padded comparison", ranks[i], ranks[first], first)
+			}
+		}
+		start = end
+	}
+
+	slices.SortStableFunc(report.Issues, func(a, b ValidationIssue) int {
+		return cmp.Or(cmp.Compare(a.Index, b.Index), cmp.Compare(b.Severity, a.Severity), cmp.Compare(a.Kind, b.Kind))
+	})
+	return report
+}
@@ -0,0 +1,161 @@
+package gexorank_test
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/lupppig/gexorank"
+)
+
+// --- Validate Tests ---
+
+func parseAll(t *testing.T, ss ...string) []gexorank.LexoRank {
+	t.Helper()
+	ranks := make([]gexorank.LexoRank, len(ss))
+	for i, s := range ss {
+		ranks[i] = mustParse(t, s)
+	}
+	return ranks
+}
+
+func TestValidate(t *testing.T) {
+	type issue struct {
+		kind         gexorank.IssueKind
+		index, other int
+	}
+	tests := []struct {
+		name  string
+		ranks []string
+		want  []issue
+	}{
+		{"clean", []string{"0|a", "0|b", "0|c"}, nil},
+		{"empty", nil, nil},
+		{"unsorted", []string{"0|b", "0|a", "0|c"}, []issue{{gexorank.IssueUnsorted, 1, 0}}},
+		{"duplicate", []string{"0|a", "0|b", "0|b"}, []issue{{gexorank.IssueDuplicate, 2, 1}}},
+		{"duplicate far apart", []string{"0|b", "0|c", "0|b"}, []issue{
+			{gexorank.IssueUnsorted, 2, 1},
+			{gexorank.IssueDuplicate, 2, 0},
+		}},
+		{"padding equivalent", []string{"0|a", "0|b", "0|b00"}, []issue{
+			{gexorank.IssueEquivalent, 2, 1},
+			{gexorank.IssueNonCanonical, 2, -1},
+		}},
+		{"mixed buckets", []string{"0|a", "1|a"}, []issue{{gexorank.IssueMixedBucket, 1, 0}}},
+		{"at min", []string{"0|000", "0|a"}, []issue{
+			{gexorank.IssueOutOfRange, 0, -1},
+			{gexorank.IssueNonCanonical, 0, -1},
+		}},
+		{"above max", []string{"0|a", "0|zzzzzzz"}, nil},
+		{"at max", []string{"0|a", "0|" + strings.Repeat("z", gexorank.MaxLength)}, []issue{
+			{gexorank.IssueOutOfRange, 1, -1},
+			{gexorank.IssueNearMaxLength, 1, -1},
+		}},
+		{"near max length", []string{"0|a", "0|b" + strings.Repeat("1", 100)}, []issue{{gexorank.IssueNearMaxLength, 1, -1}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := gexorank.Validate(parseAll(t, tt.ranks...))
+			var got []issue
+			for _, i := range report.Issues {
+				got = append(got, issue{i.Kind, i.Index, i.Other})
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Errorf("issues = %v, want %v\n%s", got, tt.want, report)
+			}
+			if report.Count != len(tt.ranks) {
+				t.Errorf("Count = %d, want %d", report.Count, len(tt.ranks))
+			}
+		})
+	}
+}
+
+func TestValidate_Severity(t *testing.T) {
+	report := gexorank.Validate(parseAll(t, "0|b", "0|a", "1|c", "0|d0"))
+	if report.OK() || report.Max() != gexorank.SeverityError {
+		t.Errorf("OK() = %v, Max() = %v; want false, error", report.OK(), report.Max())
+	}
+	for _, i := range report.Issues {
+		want := map[gexorank.IssueKind]gexorank.Severity{
+			gexorank.IssueUnsorted:     gexorank.SeverityError,
+			gexorank.IssueMixedBucket:  gexorank.SeverityWarning,
+			gexorank.IssueNonCanonical: gexorank.SeverityInfo,
+		}[i.Kind]
+		if i.Severity != want {
+			t.Errorf("%v: severity %v, want %v", i.Kind, i.Severity, want)
+		}
+	}
+	if got := len(report.Filter(gexorank.SeverityWarning)); got != 3 {
+		t.Errorf("Filter(warning) returned %d issues, want 3\n%s", got, report)
+	}
+
+	clean := gexorank.Validate(parseAll(t, "0|a", "0|b0"))
+	if !clean.OK() || clean.Max() != gexorank.SeverityInfo {
+		t.Errorf("info only: OK() = %v, Max() = %v; want true, info", clean.OK(), clean.Max())
+	}
+}
+
+func TestValidate_RankerLimits(t *testing.T) {
+	rk, err := gexorank.NewRanker(gexorank.WithMaxLength(8))
+	if err != nil {
+		t.Fatal(err)
+	}
+	a, _ := rk.Parse("0|a")
+	b, _ := rk.Parse("0|abcdef")
+	long := mustParse(t, "0|abcdefghij") // from the default ranker
+
+	report := rk.Validate([]gexorank.LexoRank{a, b, long})
+	var kinds []gexorank.IssueKind
+	for _, i := range report.Issues {
+		kinds = append(kinds, i.Kind)
+	}
+	want := []gexorank.IssueKind{gexorank.IssueNearMaxLength, gexorank.IssueTooLong}
+	if fmt.Sprint(kinds) != fmt.Sprint(want) {
+		t.Errorf("kinds = %v, want %v", kinds, want)
+	}
+}
+
+func TestValidate_GeneratedEdges(t *testing.T) {
+	for _, opts := range [][]gexorank.Option{
+		nil,
+		{gexorank.WithDefaultLength(2), gexorank.WithMaxLength(8)},
+	} {
+		rk, err := gexorank.NewRanker(opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Step down from just above Min and up from Max, as a list grown at
+		// both ends would.
+		var low []gexorank.LexoRank
+		for r := rk.Min().GenNext(); len(low) < 5; r = r.GenPrev() {
+			low = append(low, r)
+		}
+		slices.Reverse(low)
+		ranks := append(low, rk.Initial())
+		for r := rk.Max(); len(ranks) < 11; r = r.GenNext() {
+			ranks = append(ranks, r)
+		}
+
+		report := rk.Validate(ranks)
+		if issues := report.Filter(gexorank.SeverityWarning); len(issues) > 0 {
+			t.Errorf("MaxLen %d: Validate(%v) reported\n%s", rk.Max().MaxLen(), ranks, report)
+		}
+	}
+}
+
+func ExampleValidate() {
+	var ranks []gexorank.LexoRank
+	for _, s := range []string{"0|a", "0|c", "0|b", "0|c0"} {
+		r, _ := gexorank.Parse(s)
+		ranks = append(ranks, r)
+	}
+
+	report := gexorank.Validate(ranks)
+	fmt.Println(report)
+	// Output:
+	// 4 ranks, 3 issues
+	// error [unsorted] index 2: 0|b sorts before 0|c at index 1
+	// error [equivalent] index 3: 0|c0 is equal to 0|c at index 1 under padded comparison
+	// info [non-canonical] index 3: 0|c0 has trailing '0' padding
+}